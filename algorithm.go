@@ -0,0 +1,76 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hasherprovider
+
+import (
+	"fmt"
+)
+
+// Algorithm identifies a hashing algorithm of the registry. The built-in algorithms
+// are the constants CONSISTENT_HASHING to P2C_HASHING, and every algorithm added
+// with Register is given the next value. An Algorithm is written as the name it was
+// registered under, so that it can be read from JSON or YAML configurations and flags
+type Algorithm int
+
+// String returns the name the algorithm was registered under
+func (a Algorithm) String() string {
+	if r, ok := lookupAlgorithm(a); ok {
+		return r.name
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// ParseAlgorithm returns the algorithm registered under the given name, such as
+// "consistent" or "maglev". The name is matched regardless of case and surrounding
+// spaces
+func ParseAlgorithm(name string) (Algorithm, error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if a, ok := registry.ids[normalizeName(name)]; ok {
+		return a, nil
+	}
+
+	return 0, fmt.Errorf("unknown hashing algorithm: %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler, writing the name of the algorithm
+func (a Algorithm) MarshalText() ([]byte, error) {
+	r, ok := lookupAlgorithm(a)
+	if !ok {
+		return nil, fmt.Errorf("unknown hashing function type: %d", int(a))
+	}
+	return []byte(r.name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, reading the name of the algorithm
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	parsed, err := ParseAlgorithm(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package hasherprovider
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_AlgorithmParsed_THEN_MatchesConstant(t *testing.T) {
+	algorithms := []struct {
+		name string
+		algo Algorithm
+	}{
+		{"consistent", CONSISTENT_HASHING},
+		{"random", RANDOM_HASHING},
+		{"uniform", UNIFORM_HASHING},
+		{"rendezvous", RENDEZVOUS_HASHING},
+		{"jump", JUMP_HASHING},
+		{"maglev", MAGLEV_HASHING},
+		{"p2c", P2C_HASHING},
+		{" Consistent ", CONSISTENT_HASHING},
+		{"MAGLEV", MAGLEV_HASHING},
+	}
+
+	for _, a := range algorithms {
+		algo, err := ParseAlgorithm(a.name)
+		if err != nil || algo != a.algo {
+			t.Errorf("ParseAlgorithm(%q) = %d, %v; expected %d", a.name, algo, err, a.algo)
+		}
+	}
+
+	if _, err := ParseAlgorithm("modulo"); err == nil {
+		t.Error("Expected non-nil error as algorithm is unknown but got nil")
+	}
+}
+
+func TestWHEN_AlgorithmFormatted_THEN_NameReturned(t *testing.T) {
+	if CONSISTENT_HASHING.String() != "consistent" {
+		t.Errorf("Expected consistent, but got %s", CONSISTENT_HASHING)
+	}
+
+	if Algorithm(-1).String() != "Algorithm(-1)" {
+		t.Errorf("Expected Algorithm(-1), but got %s", Algorithm(-1))
+	}
+}
+
+func TestWHEN_AlgorithmInJSON_THEN_WrittenAndReadAsName(t *testing.T) {
+	type config struct {
+		Algorithm Algorithm `json:"algorithm"`
+	}
+
+	data, err := json.Marshal(config{Algorithm: RENDEZVOUS_HASHING})
+	if err != nil || string(data) != `{"algorithm":"rendezvous"}` {
+		t.Errorf("Expected the name of the algorithm, but got %s and %v", data, err)
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"algorithm":"jump"}`), &c); err != nil || c.Algorithm != JUMP_HASHING {
+		t.Errorf("Expected jump, but got %s and %v", c.Algorithm, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"algorithm":"modulo"}`), &c); err == nil {
+		t.Error("Expected non-nil error as algorithm is unknown but got nil")
+	}
+
+	if _, err := json.Marshal(config{Algorithm: Algorithm(-1)}); err == nil {
+		t.Error("Expected non-nil error as algorithm is unknown but got nil")
+	}
+}
+
+func TestWHEN_requestForHasherByName_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	hasher, err := hp.GetHasherByName("maglev")
+	if hasher == nil || err != nil {
+		t.Errorf("Unexpected error for valid algorithm maglev: %v", err)
+	}
+
+	if _, err := hp.GetHasherByName("modulo"); err == nil {
+		t.Error("Expected error for invalid algorithm modulo, but got nil")
+	}
+}
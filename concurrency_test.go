@@ -0,0 +1,172 @@
+package hasherprovider
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/consistent"
+)
+
+// These tests are meant to be run with the race detector : go test -race ./...
+
+func TestWHEN_concurrentHashAndMembershipChanges_THEN_NoRace(t *testing.T) {
+	membership := map[Algorithm]bool{
+		CONSISTENT_HASHING: true,
+		RANDOM_HASHING:     true,
+		UNIFORM_HASHING:    true,
+		RENDEZVOUS_HASHING: true,
+		MAGLEV_HASHING:     true,
+		P2C_HASHING:        true,
+	}
+
+	algos := []Algorithm{CONSISTENT_HASHING, RANDOM_HASHING, UNIFORM_HASHING, RENDEZVOUS_HASHING, JUMP_HASHING, MAGLEV_HASHING, P2C_HASHING}
+
+	for _, algo := range algos {
+		hp := HasherProvider{
+			Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+
+		hasher, err := hp.GetHasher(algo)
+		if err != nil {
+			t.Fatalf("Unexpected error for valid algorithm type %d: %v", algo, err)
+		}
+
+		if algo == CONSISTENT_HASHING {
+			SetReplicas(hasher, 10)
+		}
+
+		nodes := make(map[string]bool)
+		for i := 0; i < 8; i++ {
+			nodes[fmt.Sprintf("server%d", i)] = true
+		}
+
+		// Without nodes, uniform hashing falls back to shard indexes, hence a node
+		// which is never removed
+		nodes["server8"] = true
+		if membership[algo] {
+			AddNode(hasher, "server8")
+		}
+
+		var wg sync.WaitGroup
+
+		for r := 0; r < 8; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+
+				for i := 0; i < 500; i++ {
+					result, err := hasher.Hash(fmt.Sprintf("key-%d-%d", r, i), 16)
+					if err != nil {
+						t.Errorf("Unexpected error for valid Hashing %d : %v", algo, err)
+						return
+					}
+
+					if membership[algo] && result != "" && !nodes[result] {
+						t.Errorf("Expected key to be assigned to a known node, but got `%s`", result)
+						return
+					}
+				}
+			}(r)
+		}
+
+		if membership[algo] {
+			for w := 0; w < 2; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+
+					for i := 0; i < 20; i++ {
+						node := fmt.Sprintf("server%d", (w*4)+(i%4))
+						AddNode(hasher, node)
+						if i%3 == 0 {
+							RemoveNode(hasher, node)
+						}
+					}
+				}(w)
+			}
+		}
+
+		wg.Wait()
+	}
+}
+
+func TestWHEN_concurrentLoadsAndRingChanges_THEN_NoRace(t *testing.T) {
+	h := &consistent.ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	for i := 0; i < 4; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	var wg sync.WaitGroup
+
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+
+			for i := 0; i < 500; i++ {
+				node, _ := h.Hash(fmt.Sprintf("key-%d-%d", r, i), 0)
+				h.Inc(node)
+				h.GetN(node+"-replicas", 2)
+				h.GetPlacement(node+"-replicas", 2)
+				h.Done(node)
+			}
+		}(r)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 50; i++ {
+			node := fmt.Sprintf("server%d", 4+i%4)
+			h.AddWeightedNode(node, 2)
+			h.SetNodeInfo(node, consistent.NodeInfo{Zone: fmt.Sprintf("az%d", i%2)})
+			h.UpdateWeight(node, 0.5)
+			h.RemoveNode(node)
+		}
+	}()
+
+	wg.Wait()
+
+	for node, load := range h.Loads() {
+		if load != 0 {
+			t.Errorf("Expected node `%s` to have no load left, but got %d", node, load)
+		}
+	}
+}
+
+func TestWHEN_concurrentSettersOnNewRing_THEN_NoRace(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		h := &consistent.ConsistentHashing{
+			Replicas: 10,
+			Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+
+		var wg sync.WaitGroup
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h.SetLoadFactor(1.5)
+			h.SetReplicas(20)
+		}()
+		go func() {
+			defer wg.Done()
+			h.Hash("k", 0)
+		}()
+
+		wg.Wait()
+
+		if s := h.Snapshot(); s.LoadFactor != 1.5 || s.Replicas != 20 {
+			t.Errorf("Expected the load factor and replicas to be set, but got %f and %d", s.LoadFactor, s.Replicas)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// The batch operations change many nodes in a single new snapshot of the ring. The
+// tokens of all the added nodes are computed, sorted once and merged with the tokens
+// of the ring, and the tokens of all the removed nodes are filtered out in a single
+// pass, so that a batch costs O(V log V) for V tokens instead of one merge of the
+// whole ring per node.
+
+// AddNodes will add the given nodes in the ring with a weight of 1, in a single
+// change of the ring. As with AddNode, a token already owned by a node is NOT
+// overwritten : the first node of the list keeps the token and the collisions are
+// reported in Collisions. Adding a node which is already present has no effect
+func (h *ConsistentHashing) AddNodes(nodes []string) error {
+	h.logger().Info("AddNodes", "nodes", len(nodes))
+
+	if err := h.validateNodes(nodes); err != nil {
+		return err
+	}
+
+	return h.update(func(r *ring) error {
+		h.addMembers(r, nodes)
+
+		return nil
+	})
+}
+
+// RemoveNodes will remove the given nodes from the ring, in a single change of the
+// ring. Removing a node which is not present has no effect
+func (h *ConsistentHashing) RemoveNodes(nodes []string) error {
+	h.logger().Info("RemoveNodes", "nodes", len(nodes))
+
+	return h.update(func(r *ring) error {
+		removed := make(map[string]bool, len(nodes))
+		for _, node := range nodes {
+			if _, ok := r.members[node]; !ok {
+				h.logger().Warn("RemoveNodes unknown node", "node", node)
+				continue
+			}
+			removed[node] = true
+		}
+
+		h.removeMembers(r, removed)
+
+		return nil
+	})
+}
+
+// SetNodes will change the nodes of the ring to the given nodes, in a single change
+// of the ring. The nodes already in the ring keep their weight and their tokens, the
+// new nodes are added with a weight of 1 and the other nodes are removed
+func (h *ConsistentHashing) SetNodes(nodes []string) error {
+	h.logger().Info("SetNodes", "nodes", len(nodes))
+
+	if err := h.validateNodes(nodes); err != nil {
+		return err
+	}
+
+	return h.update(func(r *ring) error {
+		kept := make(map[string]bool, len(nodes))
+		for _, node := range nodes {
+			kept[node] = true
+		}
+
+		removed := make(map[string]bool)
+		for node := range r.members {
+			if !kept[node] {
+				removed[node] = true
+			}
+		}
+
+		h.removeMembers(r, removed)
+		h.addMembers(r, nodes)
+
+		return nil
+	})
+}
+
+// Private function not exported checking the nodes are non-empty and distinct
+func (h *ConsistentHashing) validateNodes(nodes []string) error {
+	seen := make(map[string]bool, len(nodes))
+
+	for _, node := range nodes {
+		if len(node) == 0 || seen[node] {
+			h.logger().Error("Invalid nodes", "node", node)
+			return fmt.Errorf("Expected nodes to be non-empty and distinct, but got %q", node)
+		}
+		seen[node] = true
+	}
+
+	return nil
+}
+
+// A vnode is a token of one of the nodes added by a batch, identified by the index
+// of the node in the batch
+type vnode struct {
+	token uint64
+	node  int
+}
+
+// Private function not exported to be able to add the nodes which are not in the
+// ring yet, merging the tokens of all the nodes at once
+func (h *ConsistentHashing) addMembers(r *ring, nodes []string) {
+	replicas := h.computeReplicas(1)
+
+	added := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := r.members[node]; ok {
+			h.logger().Warn("AddNodes node already present", "node", node)
+			continue
+		}
+
+		r.members[node] = &member{weight: 1, replicas: replicas, load: new(atomic.Int64)}
+		added = append(added, node)
+	}
+
+	vnodes := make([]vnode, 0, len(added)*replicas)
+	for n, node := range added {
+		for i := 0; i < replicas; i++ {
+			vnodes = append(vnodes, vnode{token: h.computeHash(r, node+strconv.Itoa(i)), node: n})
+		}
+	}
+
+	h.insertVnodes(r, vnodes, added)
+}
+
+// Private function not exported merging the given tokens of the given nodes with
+// the tokens of the ring. The tokens are sorted once, by token and then by order of
+// the nodes, and merged in a single pass. Among the nodes sharing a token, the
+// token of the ring or else the first node keeps it
+func (h *ConsistentHashing) insertVnodes(r *ring, vnodes []vnode, nodes []string) {
+	if len(vnodes) == 0 {
+		return
+	}
+
+	slices.SortFunc(vnodes, func(a, b vnode) int {
+		if c := cmp.Compare(a.token, b.token); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.node, b.node)
+	})
+
+	tokens := make([]uint64, 0, len(r.tokens)+len(vnodes))
+	owners := make([]string, 0, len(r.tokens)+len(vnodes))
+
+	i := 0
+	for j := 0; j < len(vnodes); {
+		token := vnodes[j].token
+
+		for i < len(r.tokens) && r.tokens[i] < token {
+			tokens = append(tokens, r.tokens[i])
+			owners = append(owners, r.owners[i])
+			i++
+		}
+
+		owner := nodes[vnodes[j].node]
+		if i < len(r.tokens) && r.tokens[i] == token {
+			owner = r.owners[i]
+		} else {
+			tokens = append(tokens, token)
+			owners = append(owners, owner)
+		}
+
+		for ; j < len(vnodes) && vnodes[j].token == token; j++ {
+			if node := nodes[vnodes[j].node]; node != owner {
+				h.logger().Warn("Token collision", "token", token, "node", node, "owner", owner)
+				r.collisions = append(r.collisions, Collision{Token: token, Node: node, Owner: owner})
+			}
+		}
+	}
+
+	r.tokens = append(tokens, r.tokens[i:]...)
+	r.owners = append(owners, r.owners[i:]...)
+}
+
+// Private function not exported to be able to remove the given nodes, filtering
+// their tokens and their collisions out in a single pass
+func (h *ConsistentHashing) removeMembers(r *ring, removed map[string]bool) {
+	if len(removed) == 0 {
+		return
+	}
+
+	for node := range removed {
+		delete(r.members, node)
+	}
+
+	h.removeVnodes(r, func(_ uint64, node string) bool {
+		return removed[node]
+	})
+}
+
+// Private function not exported filtering out of the ring the tokens and the
+// collisions of the virtual nodes which are gone. A token given up by its owner is
+// handed back to the nodes which collided on it, the first of them keeping it, so
+// that the ring never depends on the order of the changes
+func (h *ConsistentHashing) removeVnodes(r *ring, gone func(token uint64, node string) bool) {
+	tokens := make([]uint64, 0, len(r.tokens))
+	owners := make([]string, 0, len(r.tokens))
+
+	for i, token := range r.tokens {
+		if !gone(token, r.owners[i]) {
+			tokens = append(tokens, token)
+			owners = append(owners, r.owners[i])
+		}
+	}
+
+	r.tokens = tokens
+	r.owners = owners
+
+	var claims []vnode
+	var nodes []string
+	index := make(map[string]int)
+
+	collisions := make([]Collision, 0, len(r.collisions))
+	for _, collision := range r.collisions {
+		switch {
+		case gone(collision.Token, collision.Node):
+		case gone(collision.Token, collision.Owner):
+			n, ok := index[collision.Node]
+			if !ok {
+				n = len(nodes)
+				index[collision.Node] = n
+				nodes = append(nodes, collision.Node)
+			}
+			claims = append(claims, vnode{token: collision.Token, node: n})
+		default:
+			collisions = append(collisions, collision)
+		}
+	}
+	r.collisions = collisions
+
+	h.insertVnodes(r, claims, nodes)
+}
@@ -0,0 +1,178 @@
+package consistent
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// lowBitsHashFunc keeps 12 bits of FNV-1a 64 bits, so that the tokens of a few
+// nodes collide
+type lowBitsHashFunc struct{}
+
+func (lowBitsHashFunc) Name() string {
+	return "low-bits"
+}
+
+func (lowBitsHashFunc) Sum64(data []byte) uint64 {
+	return hashfunc.FNV1a64.Sum64(data) & 0xfff
+}
+
+func nodeNames(prefix string, n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return nodes
+}
+
+func sortedCollisions(h *ConsistentHashing) []Collision {
+	collisions := h.Collisions()
+	sort.Slice(collisions, func(i, j int) bool {
+		a, b := collisions[i], collisions[j]
+		if a.Token != b.Token {
+			return a.Token < b.Token
+		}
+		return a.Node < b.Node
+	})
+	return collisions
+}
+
+func TestWHEN_AddNodes_THEN_SameRingAsAddingNodesOneByOne(t *testing.T) {
+	batch := &ConsistentHashing{
+		Replicas: 20,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: lowBitsHashFunc{},
+	}
+	sequential := &ConsistentHashing{
+		Replicas: 20,
+		HashFunc: lowBitsHashFunc{},
+	}
+
+	batch.AddNode("server0")
+	if err := batch.AddNodes(nodeNames("server", 50)); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	for _, node := range nodeNames("server", 50) {
+		sequential.AddNode(node)
+	}
+
+	if !reflect.DeepEqual(owners(batch), owners(sequential)) {
+		t.Error("Expected the batch to assign every token to the same node as AddNode")
+	}
+
+	if len(batch.Collisions()) == 0 {
+		t.Fatal("Expected the 12 bits tokens to collide")
+	}
+
+	if !reflect.DeepEqual(sortedCollisions(batch), sortedCollisions(sequential)) {
+		t.Errorf("Expected the same collisions, but got %v and %v", batch.Collisions(), sequential.Collisions())
+	}
+}
+
+func TestWHEN_RemoveNodes_THEN_SameRingAsRemovingNodesOneByOne(t *testing.T) {
+	batch := &ConsistentHashing{
+		Replicas: 20,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: lowBitsHashFunc{},
+	}
+	sequential := &ConsistentHashing{
+		Replicas: 20,
+		HashFunc: lowBitsHashFunc{},
+	}
+
+	batch.AddNodes(nodeNames("server", 50))
+	sequential.AddNodes(nodeNames("server", 50))
+	batch.Inc("server3")
+
+	removed := append(nodeNames("server", 10), "unknown")
+	if err := batch.RemoveNodes(removed); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	for _, node := range removed {
+		sequential.RemoveNode(node)
+	}
+
+	if !reflect.DeepEqual(owners(batch), owners(sequential)) {
+		t.Error("Expected the batch to remove the same tokens as RemoveNode")
+	}
+
+	if !reflect.DeepEqual(sortedCollisions(batch), sortedCollisions(sequential)) {
+		t.Errorf("Expected the same collisions, but got %v and %v", batch.Collisions(), sequential.Collisions())
+	}
+
+	for _, node := range owners(batch) {
+		if node == "server3" {
+			t.Fatal("Expected no token of a removed node to be left in the ring")
+		}
+	}
+
+	if load := batch.load.Load(); load != 0 {
+		t.Errorf("Expected the load of the removed nodes to be dropped, but got %d", load)
+	}
+}
+
+func TestWHEN_SetNodes_THEN_MembershipReplacedKeepingExistingNodes(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddWeightedNode("server1", 2)
+	h.AddNode("server2")
+
+	before := owners(h)
+
+	if err := h.SetNodes([]string{"server1", "server3"}); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	tokens := make(map[string]int)
+	for _, node := range owners(h) {
+		tokens[node]++
+	}
+
+	if tokens["server1"] != 20 || tokens["server2"] != 0 || tokens["server3"] != 10 {
+		t.Errorf("Expected 20 tokens for `server1` and 10 for `server3`, but got %v", tokens)
+	}
+
+	for token, node := range before {
+		if node == "server1" && owners(h)[token] != "server1" {
+			t.Errorf("Expected token %d to stay owned by `server1`", token)
+		}
+	}
+
+	if err := h.SetNodes(nil); err != nil || len(h.Tokens()) != 0 {
+		t.Errorf("Expected the ring to be emptied, but got %d tokens and %v", len(h.Tokens()), err)
+	}
+}
+
+func TestWHEN_BatchWithEmptyOrDuplicateNodes_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+
+	for _, nodes := range [][]string{{"server2", ""}, {"server2", "server2"}} {
+		if err := h.AddNodes(nodes); err == nil {
+			t.Errorf("Expected non-nil error adding %q but got nil", nodes)
+		}
+
+		if err := h.SetNodes(nodes); err == nil {
+			t.Errorf("Expected non-nil error setting %q but got nil", nodes)
+		}
+	}
+
+	if len(h.Tokens()) != 10 || h.Weight("server2") != 0 {
+		t.Errorf("Expected the ring to be left unchanged, but got %d tokens", len(h.Tokens()))
+	}
+}
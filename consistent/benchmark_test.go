@@ -0,0 +1,148 @@
+package consistent
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The benchmarks build rings of up to 10k nodes with 1k replicas each, 10 millions
+// of 64 bits tokens. The largest sizes are skipped with -short
+var benchmarkSizes = []struct {
+	nodes    int
+	replicas int
+}{
+	{100, 100},
+	{1000, 100},
+	{1000, 1000},
+	{10000, 1000},
+}
+
+func newBenchmarkRing(b *testing.B, nodes int, replicas int) *ConsistentHashing {
+	if testing.Short() && nodes*replicas > 1000000 {
+		b.Skip("Skipping a ring of more than 1M tokens with -short")
+	}
+
+	h := &ConsistentHashing{Replicas: replicas}
+	h.SetTokenBits(64)
+
+	return h
+}
+
+func BenchmarkAddNodes(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			nodes := nodeNames("server", size.nodes)
+
+			for i := 0; i < b.N; i++ {
+				h := newBenchmarkRing(b, size.nodes, size.replicas)
+				h.AddNodes(nodes)
+			}
+		})
+	}
+}
+
+func BenchmarkRemoveNodes(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			nodes := nodeNames("server", size.nodes)
+			h := newBenchmarkRing(b, size.nodes, size.replicas)
+			h.AddNodes(nodes)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				h.RemoveNodes(nodes[:size.nodes/10])
+
+				b.StopTimer()
+				h.AddNodes(nodes[:size.nodes/10])
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkSetNodes(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			nodes := nodeNames("server", size.nodes+size.nodes/10)
+			h := newBenchmarkRing(b, size.nodes, size.replicas)
+			h.AddNodes(nodes[:size.nodes])
+			b.ResetTimer()
+
+			// Every change replaces 10% of the nodes
+			for i := 0; i < b.N; i++ {
+				if i%2 == 0 {
+					h.SetNodes(nodes[size.nodes/10:])
+				} else {
+					h.SetNodes(nodes[:size.nodes])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAddNode(b *testing.B) {
+	for _, size := range benchmarkSizes[:2] {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			nodes := nodeNames("server", size.nodes)
+
+			for i := 0; i < b.N; i++ {
+				h := newBenchmarkRing(b, size.nodes, size.replicas)
+				for _, node := range nodes {
+					h.AddNode(node)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRemoveNode(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			nodes := nodeNames("server", size.nodes)
+			h := newBenchmarkRing(b, size.nodes, size.replicas)
+			h.AddNodes(nodes)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				h.RemoveNode(nodes[0])
+
+				b.StopTimer()
+				h.AddNode(nodes[0])
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			h := newBenchmarkRing(b, size.nodes, size.replicas)
+			h.AddNodes(nodeNames("server", size.nodes))
+
+			keys := nodeNames("key-", 1024)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				h.Hash(keys[i%len(keys)], 0)
+			}
+		})
+	}
+}
+
+func BenchmarkBoundedHash(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("nodes=%d/replicas=%d", size.nodes, size.replicas), func(b *testing.B) {
+			h := newBenchmarkRing(b, size.nodes, size.replicas)
+			h.SetLoadFactor(1.25)
+			h.AddNodes(nodeNames("server", size.nodes))
+
+			keys := nodeNames("key-", 1024)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				h.Hash(keys[i%len(keys)], 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+// With consistent Hashing with bounded loads (Mirrokni, Thorup and Zadimoghaddam),
+// every node accepts at most LoadFactor times its share of the load, proportional to
+// its weight. A key whose immediate node is full keeps going clockwise onto the ring
+// until a node with enough capacity is found, so hot keys cannot pile onto a single
+// node.
+// The load of the nodes is reported by the caller with Inc and Done.
+
+// SetLoadFactor set the load factor bounding the load of every node, relatively to
+// the average load. The load factor must be greater or equal to 1, a load factor of 1
+// meaning that no node accepts more than the average load
+func (h *ConsistentHashing) SetLoadFactor(factor float64) error {
+	if factor < 1 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		h.logger().Error("SetLoadFactor failed", "load_factor", factor)
+		return errors.New("Expected load factor to be greater or equal to 1")
+	}
+
+	return h.update(func(r *ring) error {
+		h.LoadFactor = factor
+		r.loadFactor = factor
+
+		return nil
+	})
+}
+
+// Inc increments the load of the given node, when a request is assigned to it
+func (h *ConsistentHashing) Inc(node string) {
+	m, ok := h.snapshot().members[node]
+	if !ok {
+		h.logger().Warn("Inc unknown node", "node", node)
+		return
+	}
+
+	// a node removed since the snapshot was read has a retired load, which is no
+	// longer part of the total load
+	if m.load.Add(1) > 0 {
+		h.load.Add(1)
+	}
+}
+
+// Done decrements the load of the given node, when a request assigned to it finished
+func (h *ConsistentHashing) Done(node string) {
+	m, ok := h.snapshot().members[node]
+	if !ok {
+		h.logger().Warn("Done unknown node", "node", node)
+		return
+	}
+
+	for {
+		load := m.load.Load()
+		if load <= 0 {
+			h.logger().Warn("Done node without load", "node", node)
+			return
+		}
+
+		if m.load.CompareAndSwap(load, load-1) {
+			h.load.Add(-1)
+			return
+		}
+	}
+}
+
+// Loads returns a copy of the current load of every node
+func (h *ConsistentHashing) Loads() map[string]int64 {
+	members := h.snapshot().members
+
+	loads := make(map[string]int64, len(members))
+	for node, m := range members {
+		loads[node] = max(m.load.Load(), 0)
+	}
+	return loads
+}
+
+// MaxLoad returns the maximum load the given node accepts for the next request, given
+// the current total load, the weight of the node relatively to the total weight and
+// the load factor. Without load factor the load of the nodes is not bounded, and a
+// node not in the ring accepts no load
+func (h *ConsistentHashing) MaxLoad(node string) int64 {
+	r := h.snapshot()
+
+	m, ok := r.members[node]
+	if !ok {
+		return 0
+	}
+
+	return computeMaxLoad(r, m, h.load.Load(), r.totalWeight)
+}
+
+// GetBoundedNode will return the first node following the given key onto the ring
+// whose load is below its maximum load. Going clockwise from the immediate node, the
+// nodes already at their maximum load are skipped
+func (h *ConsistentHashing) GetBoundedNode(key string) string {
+	r := h.snapshot()
+
+	if len(r.tokens) == 0 {
+		return ""
+	}
+
+	idx := r.search(h.computeHash(r, key))
+
+	total, weight := h.load.Load(), r.totalWeight
+
+	for i := 0; i < len(r.tokens); i++ {
+		node := r.owners[(idx+i)%len(r.tokens)]
+		if m := r.members[node]; m.load.Load() < computeMaxLoad(r, m, total, weight) {
+			if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+				logger.Debug("GetBoundedNode", logging.KeyHash(key), "node", node, "skipped", i)
+			}
+			return node
+		}
+	}
+
+	h.logger().Warn("GetBoundedNode every node at the maximum load", logging.KeyHash(key), "total_load", total)
+
+	return r.owners[idx%len(r.tokens)]
+}
+
+// Private function not exported computing the maximum load of a node of the ring, the
+// share of the total load given by its weight times the load factor
+func computeMaxLoad(r *ring, m *member, total int64, weight float64) int64 {
+	if r.loadFactor == 0 {
+		return math.MaxInt64
+	}
+
+	share := float64(total+1) * m.weight / weight
+
+	return int64(math.Ceil(share * r.loadFactor))
+}
+
+// retiredLoad is the load of a node removed from the ring. It is low enough for the
+// increments of the concurrent Inc calls to never make it positive
+const retiredLoad = math.MinInt64 / 2
+
+// Private function not exported retiring the load of a node removed from the ring,
+// and returning the load it had. An Inc racing the removal either counts in the
+// returned load, or sees the retired load and leaves the total load unchanged
+func retireLoad(m *member) int64 {
+	return max(m.load.Swap(retiredLoad), 0)
+}
@@ -0,0 +1,174 @@
+package consistent
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_SetLoadFactorLowerThanOne_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 3,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, factor := range []float64{-1, 0, 0.5} {
+		if err := h.SetLoadFactor(factor); err == nil {
+			t.Errorf("Expected non-nil error for load factor %f but got nil", factor)
+		}
+	}
+
+	if err := h.SetLoadFactor(1.25); err != nil || h.LoadFactor != 1.25 {
+		t.Errorf("Expected load factor 1.25 to be accepted, but got %f and %v", h.LoadFactor, err)
+	}
+}
+
+func TestWHEN_NoLoad_THEN_BoundedNodeIsImmediateNode(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if h.GetBoundedNode(key) != h.GetImmediateNode(key) {
+			t.Errorf("Expected key `%s` to be assigned to its immediate node without load", key)
+		}
+	}
+}
+
+func TestWHEN_HotKeyWithLoadFactor_THEN_LoadBounded(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+	h.AddNode("server4")
+
+	for i := 0; i < 100; i++ {
+		node, err := h.Hash("hot-tenant", 0)
+		if err != nil {
+			t.Errorf("Expected no errors to occur but got %s", err)
+		}
+
+		maxLoad := h.MaxLoad(node)
+
+		if h.Loads()[node] >= maxLoad {
+			t.Errorf("Expected node `%s` to be below the maximum load %d", node, maxLoad)
+		}
+
+		h.Inc(node)
+	}
+
+	// 100 requests over 4 nodes with a load factor of 1.25
+	for node, load := range h.Loads() {
+		if load > 32 {
+			t.Errorf("Expected node `%s` to have a load lower or equal to 32, but got %d", node, load)
+		}
+	}
+}
+
+func TestWHEN_IncAndDone_THEN_LoadsUpdated(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 3,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+
+	h.Inc("server1")
+	h.Inc("server1")
+	h.Inc("server2")
+	h.Inc("unknown")
+	h.Done("server1")
+	h.Done("server2")
+	h.Done("server2")
+
+	loads := h.Loads()
+	if len(loads) != 2 || loads["server1"] != 1 || loads["server2"] != 0 {
+		t.Errorf("Expected loads server1=1 and server2=0, but got %v", loads)
+	}
+
+	h.RemoveNode("server1")
+
+	if _, ok := h.Loads()["server1"]; ok || h.load.Load() != 0 {
+		t.Errorf("Expected the load of the removed node to be dropped, but got %v", h.Loads())
+	}
+}
+
+func TestWHEN_IncRacesRemoveNode_THEN_LoadOfRemovedNodeDropped(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	h.AddNode("server1")
+	h.AddNode("server2")
+
+	// Inc read the node from the ring before RemoveNode, and increments it after
+	m := h.snapshot().members["server2"]
+	h.RemoveNode("server2")
+	m.load.Add(1)
+
+	// a single node without load accepts ceil(1 * 1.25) requests
+	if maxLoad := h.MaxLoad("server1"); maxLoad != 2 {
+		t.Errorf("Expected the load of the removed node to be dropped, but got a maximum load of %d", maxLoad)
+	}
+}
+
+func TestWHEN_WeightedNodes_THEN_MaxLoadScaledByWeight(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	h.AddNode("small")
+	h.AddWeightedNode("big", 3)
+
+	for i := 0; i < 39; i++ {
+		h.Inc("small")
+	}
+
+	// 40 requests, of which the big node accepts 3/4 and the small node 1/4, times 1.25
+	if small, big := h.MaxLoad("small"), h.MaxLoad("big"); small != 13 || big != 38 {
+		t.Errorf("Expected maximum loads of 13 and 38, but got %d and %d", small, big)
+	}
+
+	if maxLoad := h.MaxLoad("unknown"); maxLoad != 0 {
+		t.Errorf("Expected a node not in the ring to accept no load, but got %d", maxLoad)
+	}
+}
+
+func TestWHEN_HotKeyWithWeightedNodes_THEN_LoadProportionalToWeight(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetLoadFactor(1.25)
+	h.AddNode("small")
+	h.AddWeightedNode("big", 3)
+
+	for i := 0; i < 400; i++ {
+		node, _ := h.Hash("hot-tenant", 0)
+		h.Inc(node)
+	}
+
+	// 400 requests, of which the small node accepts at most ceil(400 / 4 * 1.25)
+	if loads := h.Loads(); loads["small"] > 125 || loads["big"] < 275 {
+		t.Errorf("Expected the big node to take about 3 times the load of the small node, but got %v", loads)
+	}
+}
@@ -23,59 +23,154 @@
 package consistent
 
 import (
+	"context"
 	"errors"
-	"hash/fnv"
-	"log"
+	"log/slog"
 	"sort"
-	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
 )
 
 // With consistent Hashing, the keys already assigned to a shard
 // do NOT need to be reassigned. Hence solving the issue introduced
 // by the usage of Modulo to be able to perform a consistent Hashing.
+//
+// ConsistentHashing is safe for concurrent use. Every change of the ring builds a
+// new immutable snapshot of the ring under a lock, then publishes it atomically, so
+// that lookups never take a lock and always see a complete ring.
+// The exported fields configure the ring and must NOT be changed once the ring is
+// in use, the setters must be used instead.
+
+// DefaultReplicas is the number of virtual nodes of a node of weight 1 in the rings
+// returned by GetHasher, unless other replicas are set with WithReplicas or
+// SetReplicas. A ConsistentHashing created directly has no default replicas
+const DefaultReplicas = 100
 
 type ConsistentHashing struct {
-	Nodes    map[uint32]string
-	Replicas int
-	Keys     []uint32
-	Logger   *log.Logger
+	Replicas   int
+	Logger     *slog.Logger
+	HashFunc   hashfunc.HashFunc
+	TokenBits  int
+	LoadFactor float64
+	mu         sync.Mutex
+	ring       atomic.Pointer[ring]
+	// load is the total load of the nodes of the ring, kept up to date by Inc, Done
+	// and the changes removing nodes, so that the lookups never sum the loads
+	load atomic.Int64
+}
+
+// A ring is an immutable snapshot of the ring. The owner of tokens[i] is owners[i],
+// and the tokens are sorted
+type ring struct {
+	tokens     []uint64
+	owners     []string
+	members    map[string]*member
+	collisions []Collision
+	moves      []Move
+	tokenBits  int
+	loadFactor float64
+	// totalWeight is the sum of the weights of the members, computed once the ring
+	// is built
+	totalWeight float64
+}
+
+// A Collision is reported when the token of a node is already owned by another
+// node of the ring. The token stays owned by its first owner
+type Collision struct {
+	Token uint64 `json:"token"`
+	Node  string `json:"node"`
+	Owner string `json:"owner"`
+}
+
+// SetTokenBits set the size of the tokens of the ring, either 32 or 64 bits.
+// With thousands of nodes and hundreds of replicas, 64 bits tokens make collisions
+// very unlikely. The size of the tokens can only be changed while the ring is empty
+func (h *ConsistentHashing) SetTokenBits(bits int) error {
+	if bits != 32 && bits != 64 {
+		h.logger().Error("SetTokenBits failed", "token_bits", bits)
+		return errors.New("Expected token bits to be 32 or 64")
+	}
+
+	return h.update(func(r *ring) error {
+		if len(r.tokens) != 0 {
+			h.logger().Error("SetTokenBits failed on a non-empty ring", "token_bits", bits)
+			return errors.New("Expected ring to be empty to change token bits")
+		}
+
+		h.TokenBits = bits
+		r.tokenBits = bits
+
+		return nil
+	})
+}
+
+// Collisions returns the collisions detected while adding the nodes of the ring
+// which are still part of the ring
+func (h *ConsistentHashing) Collisions() []Collision {
+	return append([]Collision(nil), h.snapshot().collisions...)
 }
 
-// SetReplicas set the replicas for the entities to be hashed in the ring
-func (h *ConsistentHashing) SetReplicas(replicas int) {
-	h.Replicas = replicas
+// Tokens returns the sorted tokens of the ring
+func (h *ConsistentHashing) Tokens() []uint64 {
+	return append([]uint64(nil), h.snapshot().tokens...)
+}
+
+// Owner returns the node owning the given token, and whether the token is in the ring
+func (h *ConsistentHashing) Owner(token uint64) (string, bool) {
+	r := h.snapshot()
+
+	idx := r.search(token)
+	if idx == len(r.tokens) || r.tokens[idx] != token {
+		return "", false
+	}
+
+	return r.owners[idx], true
 }
 
 // AddNode will add a node or entity in the ring using its hashed value
-// The ring is then ordered by the hashed value and saved in Keys
-func (h *ConsistentHashing) AddNode(node string) {
-	h.Logger.Println("[INFO] AddNode ", node)
+// The ring is then ordered by the hashed value.
+// A token already owned by another node is NOT overwritten, the collision is
+// reported in Collisions instead. Adding a node which is already present has no effect
+func (h *ConsistentHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
 
-	for i := 0; i < h.Replicas; i++ {
-		key := h.computeHash(node + strconv.Itoa(i))
-		h.Nodes[key] = node
-		h.Keys = append(h.Keys, key)
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
 	}
 
-	sort.Slice(h.Keys, func(i, j int) bool {
-		return h.Keys[i] < h.Keys[j]
+	return h.update(func(r *ring) error {
+		if _, ok := r.members[node]; ok {
+			h.logger().Warn("AddNode node already present", "node", node)
+			return nil
+		}
+
+		h.addMember(r, node, 1)
+
+		return nil
 	})
 }
 
-// RemoveNode will remove a node or entity from the ring. Then we will also
-// make sure that the Keys are consistent are removal of a node
-func (h *ConsistentHashing) RemoveNode(node string) {
-	h.Logger.Println("[INFO] RemoveNode ", node)
+// RemoveNode will remove a node or entity from the ring. Every token owned by the
+// node is filtered out of the ring in a single pass, and the tokens it won over
+// colliding nodes are handed back to them.
+// Removing a node which is not present has no effect
+func (h *ConsistentHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
 
-	for i := 0; i < h.Replicas; i++ {
-		key := h.computeHash(node + strconv.Itoa(i))
-		delete(h.Nodes, key)
-		for j := range h.Keys {
-			if h.Keys[j] == key {
-				h.Keys = append(h.Keys[:j], h.Keys[j+1:]...)
-			}
+	return h.update(func(r *ring) error {
+		if _, ok := r.members[node]; !ok {
+			h.logger().Warn("RemoveNode unknown node", "node", node)
+			return nil
 		}
-	}
+
+		h.removeMembers(r, map[string]bool{node: true})
+
+		return nil
+	})
 }
 
 // GetImmediateNode will return the first node following the given node
@@ -83,30 +178,169 @@ func (h *ConsistentHashing) RemoveNode(node string) {
 // In the case of servers, the Immediate node will represent the server to send
 // data to.
 func (h *ConsistentHashing) GetImmediateNode(key string) string {
-	h.Logger.Println("[INFO] GetImmediateNode ", key)
+	r := h.snapshot()
 
-	if len(h.Nodes) == 0 {
+	if len(r.tokens) == 0 {
 		return ""
 	}
 
-	hash := h.computeHash(key)
-
-	idx := sort.Search(len(h.Keys), func(i int) bool {
-		return h.Keys[i] >= hash
-	})
+	idx := r.search(h.computeHash(r, key))
 
-	if idx == len(h.Keys) {
+	if idx == len(r.tokens) {
 		idx = 0
 	}
 
-	return h.Nodes[h.Keys[idx]]
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("GetImmediateNode", logging.KeyHash(key), "node", r.owners[idx])
+	}
+
+	return r.owners[idx]
 }
 
-// Private function not exported to be able to compute the hash of the provided key
-func (h *ConsistentHashing) computeHash(uuid string) uint32 {
-	hash := fnv.New32a()
-	hash.Write([]byte(uuid))
-	return hash.Sum32()
+// GetN will return the n distinct nodes found going clockwise onto the ring from
+// the given key, skipping the virtual nodes of the nodes already found. The first
+// node is the immediate node and the following ones form the preference list used
+// to place replicas and pick fallback nodes.
+// If n is greater than the number of nodes, all the nodes are returned
+func (h *ConsistentHashing) GetN(key string, n int) ([]string, error) {
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("GetN", logging.KeyHash(key), "n", n)
+	}
+
+	if len(key) == 0 || n <= 0 {
+		h.logger().Error("GetN failed", logging.KeyHash(key), "n", n)
+		return nil, errors.New("Expected key to be non-empty and n to be positive non 0")
+	}
+
+	r := h.snapshot()
+
+	if len(r.tokens) == 0 {
+		return []string{}, nil
+	}
+
+	idx := r.search(h.computeHash(r, key))
+
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+
+	for i := 0; i < len(r.tokens) && len(nodes) < n; i++ {
+		node := r.owners[(idx+i)%len(r.tokens)]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// Private function not exported to be able to compute the hash of the provided key.
+// With 32 bits tokens, the ring uses the low 32 bits of the hash function, FNV-1a
+// 32 bits by default. With 64 bits tokens, FNV-1a 64 bits is used by default
+func (h *ConsistentHashing) computeHash(r *ring, uuid string) uint64 {
+	if r.tokenBits == 64 {
+		if h.HashFunc == nil {
+			return hashfunc.FNV1a64.Sum64([]byte(uuid))
+		}
+		return h.HashFunc.Sum64([]byte(uuid))
+	}
+
+	hashFunc := h.HashFunc
+	if hashFunc == nil {
+		hashFunc = hashfunc.FNV1a32
+	}
+	return uint64(uint32(hashFunc.Sum64([]byte(uuid))))
+}
+
+// Private function not exported returning the current snapshot of the ring. The
+// first snapshot is created under the lock, so that the exported fields configuring
+// it are never read while a setter changes them
+func (h *ConsistentHashing) snapshot() *ring {
+	if r := h.ring.Load(); r != nil {
+		return r
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.current()
+}
+
+// Private function not exported returning the current snapshot of the ring. Before
+// the first change, an empty ring configured by the exported fields is stored. It
+// must be called with the lock held
+func (h *ConsistentHashing) current() *ring {
+	if r := h.ring.Load(); r != nil {
+		return r
+	}
+
+	r := &ring{
+		members:    map[string]*member{},
+		tokenBits:  h.TokenBits,
+		loadFactor: h.LoadFactor,
+	}
+	h.ring.Store(r)
+
+	return r
+}
+
+// Private function not exported to be able to change the ring. The change is
+// applied under the lock to a copy of the current snapshot, which replaces the
+// current snapshot unless the change failed. The total weight of the new snapshot
+// is computed, and the load of the nodes removed by the change is retired from the
+// total load
+func (h *ConsistentHashing) update(change func(r *ring) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.current()
+	r := previous.clone()
+
+	if err := change(r); err != nil {
+		return err
+	}
+
+	r.totalWeight = 0
+	for _, m := range r.members {
+		r.totalWeight += m.weight
+	}
+
+	for node, m := range previous.members {
+		if kept, ok := r.members[node]; !ok || kept.load != m.load {
+			h.load.Add(-retireLoad(m))
+		}
+	}
+
+	h.ring.Store(r)
+
+	return nil
+}
+
+// Private function not exported returning a copy of the ring which can be changed
+// without changing the snapshot. The members are copied on change, and the moves of
+// the snapshot are NOT copied, as they only describe the change which built it
+func (r *ring) clone() *ring {
+	members := make(map[string]*member, len(r.members))
+	for node, m := range r.members {
+		members[node] = m
+	}
+
+	return &ring{
+		tokens:     r.tokens,
+		owners:     r.owners,
+		members:    members,
+		collisions: r.collisions[:len(r.collisions):len(r.collisions)],
+		tokenBits:  r.tokenBits,
+		loadFactor: r.loadFactor,
+	}
+}
+
+// Private function not exported returning the index of the first token greater or
+// equal to the given hash, or the number of tokens when there is none
+func (r *ring) search(hash uint64) int {
+	return sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i] >= hash
+	})
 }
 
 // Hash hashes the given input using a graph-based data-structure and keeps a sorted list of nodes
@@ -114,11 +348,19 @@ func (h *ConsistentHashing) computeHash(uuid string) uint32 {
 // It returns the immediate node index to which the uuid will be assigned
 func (h *ConsistentHashing) Hash(uuid string, _ int) (string, error) {
 	if len(uuid) == 0 {
-		h.Logger.Println("[ERROR] Consistent Hashing ", uuid, " failed")
+		h.logger().Error("Hash failed", logging.KeyHash(uuid))
 		return "", errors.New("Expected uuid to be non-empty")
 	}
 
-	h.Logger.Println("[INFO] Hashing ", uuid, " succeeded")
+	if h.snapshot().loadFactor > 0 {
+		return h.GetBoundedNode(uuid), nil
+	}
 
 	return h.GetImmediateNode(uuid), nil
 }
+
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *ConsistentHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
+}
@@ -1,32 +1,31 @@
 package consistent
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
 )
 
 func TestWHEN_AddNodeWithReplicasCalledForConsistentHashFunction_THEN_MatchNumberOfReplicas(t *testing.T) {
 	h := &ConsistentHashing{
-		Nodes:    make(map[uint32]string),
 		Replicas: 3,
-		Keys:     make([]uint32, 0),
-		Logger:   log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	h.AddNode("node1")
 
-	if len(h.Nodes) != 3 {
-		t.Errorf("Expected 3 nodes, but got %d", len(h.Nodes))
+	if len(owners(h)) != 3 {
+		t.Errorf("Expected 3 nodes, but got %d", len(owners(h)))
 	}
 }
 
 func TestWHEN_AddNodeWithReplicasCalledForConsistentHashFunction_THEN_MatchSameEventToSameReplica(t *testing.T) {
 	h := &ConsistentHashing{
-		Nodes:    make(map[uint32]string),
 		Replicas: 3,
-		Keys:     make([]uint32, 0),
-		Logger:   log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	h.AddNode("node1")
@@ -41,9 +40,8 @@ func TestWHEN_AddNodeWithReplicasCalledForConsistentHashFunction_THEN_MatchSameE
 
 func TestWHEN_AddAndRemoveDifferentNodeWithReplicasCalledForConsistentHashFunction_THEN_MatchSameEventToSameReplica(t *testing.T) {
 	h := &ConsistentHashing{
-		Nodes:    make(map[uint32]string),
 		Replicas: 0,
-		Logger:   log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	requestedNode := "hello"
@@ -76,9 +74,8 @@ func TestWHEN_AddAndRemoveDifferentNodeWithReplicasCalledForConsistentHashFuncti
 
 func TestWHEN_providedWithEmptyUUID_THEN_ReturnEmptyString(t *testing.T) {
 	h := &ConsistentHashing{
-		Nodes:    make(map[uint32]string),
 		Replicas: 0,
-		Logger:   log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	requestedNode := ""
@@ -97,23 +94,276 @@ func TestWHEN_providedWithEmptyUUID_THEN_ReturnEmptyString(t *testing.T) {
 
 func TestWHEN_SetReplicas_THEN_ReplicasCorrectlySet(t *testing.T) {
 	h := &ConsistentHashing{
-		Nodes:    make(map[uint32]string),
 		Replicas: 0,
-		Logger:   log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	h.SetReplicas(100)
 	h.AddNode("test")
 
-	if len(h.Nodes) != 100 {
-		t.Errorf("Expected the number of nodes to be a factor of the number of replicas, but got %d", len(h.Nodes))
+	if len(owners(h)) != 100 {
+		t.Errorf("Expected the number of nodes to be a factor of the number of replicas, but got %d", len(owners(h)))
 	}
 
 	h.SetReplicas(1000)
 	h.AddNode("test-server-x")
 	h.AddNode("test-server-y")
 
-	if len(h.Nodes) != 2100 {
-		t.Errorf("Expected the number of nodes to be a factor of the number of replicas, but got %d", len(h.Nodes))
+	if len(owners(h)) != 3000 {
+		t.Errorf("Expected the existing node to be re-tokenized with the new replicas, but got %d", len(owners(h)))
+	}
+}
+
+func TestWHEN_HashFuncSet_THEN_RingUsesHashFunc(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 2,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: hashfunc.XXHash64,
+	}
+
+	h.AddNode("server1")
+
+	for _, token := range []string{"server10", "server11"} {
+		key := uint64(uint32(hashfunc.XXHash64.Sum64([]byte(token))))
+		if owners(h)[key] != "server1" {
+			t.Errorf("Expected token `%s` to be placed at %d using the hash function", token, key)
+		}
+	}
+}
+
+func TestWHEN_HashFuncNotSet_THEN_RingUsesFNV1a32(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 1,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+
+	if key := hashfunc.FNV1a32.Sum64([]byte("server10")); owners(h)[key] != "server1" {
+		t.Errorf("Expected token `server10` to be placed at %d using FNV-1a 32 bits", key)
+	}
+}
+
+type constantHashFunc struct{}
+
+func (constantHashFunc) Name() string {
+	return "constant"
+}
+
+func (constantHashFunc) Sum64(_ []byte) uint64 {
+	return 42
+}
+
+func TestWHEN_SetTokenBits_THEN_OnlyAcceptedOnEmptyRing(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 2,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := h.SetTokenBits(48); err == nil {
+		t.Error("Expected non-nil error as token bits is 48 but got nil")
+	}
+
+	if err := h.SetTokenBits(64); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	h.AddNode("server1")
+
+	if err := h.SetTokenBits(32); err == nil {
+		t.Error("Expected non-nil error as the ring is not empty but got nil")
+	}
+}
+
+func TestWHEN_TokenBitsIs64_THEN_TokensUse64Bits(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 100,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.SetTokenBits(64)
+	h.AddNode("server1")
+	h.AddNode("server2")
+
+	large := 0
+	for _, key := range h.Tokens() {
+		if key > 1<<32 {
+			large++
+		}
+	}
+
+	if large == 0 {
+		t.Error("Expected tokens to be spread over the 64 bits range")
+	}
+
+	if key := hashfunc.FNV1a64.Sum64([]byte("server10")); owners(h)[key] != "server1" {
+		t.Errorf("Expected token `server10` to be placed at %d using FNV-1a 64 bits", key)
+	}
+
+	node, _ := h.Hash("hello", 0)
+	if node != "server1" && node != "server2" {
+		t.Errorf("Expected key to be assigned to one of the nodes, but got `%s`", node)
+	}
+}
+
+func TestWHEN_TokensCollide_THEN_CollisionReportedAndOwnerKept(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 1,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: constantHashFunc{},
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+
+	if owners(h)[42] != "server1" || len(h.Tokens()) != 1 {
+		t.Errorf("Expected token 42 to stay owned by `server1`, but got `%s`", owners(h)[42])
+	}
+
+	collisions := h.Collisions()
+	expected := Collision{Token: 42, Node: "server2", Owner: "server1"}
+	if len(collisions) != 1 || collisions[0] != expected {
+		t.Errorf("Expected collision %v, but got %v", expected, collisions)
+	}
+
+	h.RemoveNode("server2")
+
+	if owners(h)[42] != "server1" || len(h.Collisions()) != 0 {
+		t.Errorf("Expected removing `server2` to keep the token of `server1`, but got `%s`", owners(h)[42])
+	}
+}
+
+func TestWHEN_OwnerOfCollidingTokenRemoved_THEN_CollidingNodeClaimsToken(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 1,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: constantHashFunc{},
+	}
+
+	h.AddNode("a")
+	h.AddNode("b")
+	h.AddNode("c")
+	h.RemoveNode("a")
+
+	if owners(h)[42] != "b" || h.GetImmediateNode("key") != "b" {
+		t.Errorf("Expected `b` to claim token 42, but got `%s`", owners(h)[42])
+	}
+
+	collisions := h.Collisions()
+	expected := Collision{Token: 42, Node: "c", Owner: "b"}
+	if len(collisions) != 1 || collisions[0] != expected {
+		t.Errorf("Expected collision %v, but got %v", expected, collisions)
+	}
+
+	h.RemoveNodes([]string{"b"})
+
+	if owners(h)[42] != "c" || len(h.Collisions()) != 0 {
+		t.Errorf("Expected `c` to claim token 42, but got `%s` and %v", owners(h)[42], h.Collisions())
+	}
+}
+
+func TestWHEN_GetN_THEN_DistinctNodesStartingWithImmediateNode(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+	h.AddNode("server4")
+
+	nodes, err := h.GetN("hello", 3)
+	if err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	if len(nodes) != 3 || nodes[0] == nodes[1] || nodes[1] == nodes[2] || nodes[0] == nodes[2] {
+		t.Errorf("Expected 3 distinct nodes, but got %v", nodes)
+	}
+
+	if nodes[0] != h.GetImmediateNode("hello") {
+		t.Errorf("Expected the first node to be the immediate node `%s`, but got `%s`", h.GetImmediateNode("hello"), nodes[0])
+	}
+
+	nodes, _ = h.GetN("hello", 10)
+	if len(nodes) != 4 {
+		t.Errorf("Expected all the 4 nodes to be returned, but got %v", nodes)
+	}
+
+	if _, err := h.GetN("hello", 0); err == nil {
+		t.Error("Expected non-nil error as n is 0 but got nil")
+	}
+
+	if _, err := h.GetN("", 2); err == nil {
+		t.Error("Expected non-nil error as key is empty but got nil")
+	}
+}
+
+func TestWHEN_GetNAfterRemoveNode_THEN_NextNodeTakesOver(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before, _ := h.GetN(key, 2)
+
+		h.RemoveNode(before[0])
+		after := h.GetImmediateNode(key)
+		h.AddNode(before[0])
+
+		if after != before[1] {
+			t.Errorf("Expected key `%s` to fall back to `%s`, but got `%s`", key, before[1], after)
+		}
+	}
+}
+
+// owners returns the owner of every token of the ring
+func owners(h *ConsistentHashing) map[uint64]string {
+	owners := make(map[uint64]string)
+	for _, token := range h.Tokens() {
+		owners[token], _ = h.Owner(token)
+	}
+	return owners
+}
+
+func TestWHEN_AddNodeWithEmptyNodeOrNegativeReplicas_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 3,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := h.AddNode(""); err == nil {
+		t.Error("Expected non-nil error as node is empty but got nil")
+	}
+
+	if err := h.SetReplicas(-1); err == nil || h.Replicas != 3 {
+		t.Errorf("Expected non-nil error as replicas is negative but got %v", err)
+	}
+
+	if err := h.RemoveNode("unknown"); err != nil {
+		t.Errorf("Expected removing an unknown node to have no effect, but got %s", err)
+	}
+}
+
+func TestWHEN_NoLogger_THEN_LookupsDoNotBuildLogRecords(t *testing.T) {
+	h := &ConsistentHashing{Replicas: 10}
+	h.AddNodes([]string{"server1", "server2", "server3"})
+
+	// the key is only copied once, to be hashed
+	if allocs := testing.AllocsPerRun(100, func() { h.Hash("key-1", 0) }); allocs > 1 {
+		t.Errorf("Expected Hash to allocate at most once, but got %f allocations", allocs)
+	}
+
+	h.SetLoadFactor(1.25)
+
+	if allocs := testing.AllocsPerRun(100, func() { h.Hash("key-1", 0) }); allocs > 1 {
+		t.Errorf("Expected bounded Hash to allocate at most once, but got %f allocations", allocs)
 	}
 }
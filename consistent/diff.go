@@ -0,0 +1,141 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A key is owned by the node of the first token greater or equal to its hash, going
+// clockwise onto the ring. Token i therefore owns the hashes from the previous token
+// (excluded) to token i (included), and the first token also owns the hashes after
+// the last token. Comparing the tokens of two rings gives the exact ranges of hashes
+// which changed owner, without sampling keys.
+
+// A Move is a range of hashes, from Start to End both included, whose owner changed
+// From a node To another node. From is empty when the range had no owner, and To is
+// empty when the range has no owner anymore
+type Move struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Diff returns the ranges of hashes whose owner changed between the ring before and
+// the ring after a change, such as AddNode, RemoveNode or SetReplicas, ordered by
+// hash. Adjacent ranges moving between the same nodes are merged. Both rings must
+// use the same hash function and size of tokens
+func Diff(before *ConsistentHashing, after *ConsistentHashing) ([]Move, error) {
+	if before == nil || after == nil {
+		return nil, errors.New("Expected rings to be non-nil")
+	}
+
+	b, a := before.snapshot(), after.snapshot()
+
+	if b.tokenBits != a.tokenBits && (b.tokenBits == 64 || a.tokenBits == 64) {
+		return nil, fmt.Errorf("Expected rings with the same token bits, but got %d and %d", b.tokenBits, a.tokenBits)
+	}
+
+	if bf, af := before.hashFuncName(b.tokenBits), after.hashFuncName(a.tokenBits); bf != af {
+		return nil, fmt.Errorf("Expected rings with the same hash function, but got %s and %s", bf, af)
+	}
+
+	if before.fingerprint(b.tokenBits) != after.fingerprint(a.tokenBits) {
+		return nil, errors.New("Expected rings with the same seed or key of their hash function")
+	}
+
+	return diffRings(b, a), nil
+}
+
+// Private function not exported returning the ranges of hashes whose owner changed
+// between the given rings. The boundaries of the ranges are the tokens of both rings,
+// walked in order with one cursor per ring
+func diffRings(before *ring, after *ring) []Move {
+	last := uint64(math.MaxUint32)
+	if before.tokenBits == 64 {
+		last = math.MaxUint64
+	}
+
+	owner := func(r *ring, idx int) string {
+		switch {
+		case len(r.tokens) == 0:
+			return ""
+		case idx == len(r.tokens):
+			return r.owners[0]
+		default:
+			return r.owners[idx]
+		}
+	}
+
+	var moves []Move
+
+	record := func(start uint64, end uint64, from string, to string) {
+		if from == to {
+			return
+		}
+
+		if n := len(moves); n > 0 && moves[n-1].End+1 == start && moves[n-1].From == from && moves[n-1].To == to {
+			moves[n-1].End = end
+			return
+		}
+
+		moves = append(moves, Move{Start: start, End: end, From: from, To: to})
+	}
+
+	i, j := 0, 0
+	start := uint64(0)
+
+	for i < len(before.tokens) || j < len(after.tokens) {
+		// the range ends at the smallest token not walked yet, and is owned in each
+		// ring by the owner of its first token greater or equal to the end
+		end := uint64(0)
+		switch {
+		case j == len(after.tokens) || (i < len(before.tokens) && before.tokens[i] <= after.tokens[j]):
+			end = before.tokens[i]
+		default:
+			end = after.tokens[j]
+		}
+
+		record(start, end, owner(before, i), owner(after, j))
+
+		if i < len(before.tokens) && before.tokens[i] == end {
+			i++
+		}
+		if j < len(after.tokens) && after.tokens[j] == end {
+			j++
+		}
+
+		if end == last {
+			return moves
+		}
+		start = end + 1
+	}
+
+	// the hashes after the last token are owned by the first token
+	record(start, last, owner(before, i), owner(after, j))
+
+	return moves
+}
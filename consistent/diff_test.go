@@ -0,0 +1,174 @@
+package consistent
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// Private function not exported checking, key by key, that a key changed owner
+// exactly when its hash is in one of the moves, from and to the nodes of the move
+func assertMoves(t *testing.T, before *ConsistentHashing, after *ConsistentHashing, moves []Move) {
+	t.Helper()
+
+	r := after.snapshot()
+
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		hash := after.computeHash(r, key)
+
+		from := before.GetImmediateNode(key)
+		to := after.GetImmediateNode(key)
+
+		var move *Move
+		for m := range moves {
+			if moves[m].Start <= hash && hash <= moves[m].End {
+				move = &moves[m]
+			}
+		}
+
+		switch {
+		case from == to && move != nil:
+			t.Errorf("Expected key `%s` owned by `%s` to not be in a move, but got %+v", key, from, *move)
+		case from != to && move == nil:
+			t.Errorf("Expected key `%s` moving from `%s` to `%s` to be in a move", key, from, to)
+		case from != to && (move.From != from || move.To != to):
+			t.Errorf("Expected key `%s` to move from `%s` to `%s`, but got %+v", key, from, to, *move)
+		}
+	}
+
+	for m := 1; m < len(moves); m++ {
+		if moves[m-1].End >= moves[m].Start {
+			t.Errorf("Expected moves to be ordered and disjoint, but got %+v and %+v", moves[m-1], moves[m])
+		}
+	}
+}
+
+// Private function not exported returning a copy of the ring, to compare it with the
+// ring after a change
+func cloneRing(h *ConsistentHashing) *ConsistentHashing {
+	c := &ConsistentHashing{
+		HashFunc: h.HashFunc,
+		Logger:   h.Logger,
+	}
+	c.Restore(h.Snapshot())
+
+	return c
+}
+
+func TestWHEN_AddNode_THEN_DiffMovesRangesToNewNode(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 20,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	before := cloneRing(h)
+	h.AddNode("server4")
+
+	moves, err := Diff(before, h)
+	if err != nil {
+		t.Fatalf("Unexpected error computing the diff: %v", err)
+	}
+
+	if len(moves) == 0 || len(moves) > 20 {
+		t.Errorf("Expected at most one move per token of the new node, but got %d", len(moves))
+	}
+
+	for _, move := range moves {
+		if move.To != "server4" || move.From == "server4" {
+			t.Errorf("Expected every move to go to server4, but got %+v", move)
+		}
+	}
+
+	assertMoves(t, before, h, moves)
+}
+
+func TestWHEN_RemoveNode_THEN_DiffMovesRangesFromRemovedNode(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas:  20,
+		TokenBits: 64,
+		HashFunc:  hashfunc.Murmur3,
+		Logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	before := cloneRing(h)
+	h.RemoveNode("server2")
+
+	moves, err := Diff(before, h)
+	if err != nil {
+		t.Fatalf("Unexpected error computing the diff: %v", err)
+	}
+
+	for _, move := range moves {
+		if move.From != "server2" {
+			t.Errorf("Expected every move to come from server2, but got %+v", move)
+		}
+	}
+
+	assertMoves(t, before, h, moves)
+}
+
+func TestWHEN_RingEmptied_THEN_DiffCoversAllHashes(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 5,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	empty := cloneRing(h)
+	h.AddNode("server1")
+
+	moves, _ := Diff(empty, h)
+
+	if len(moves) != 1 || moves[0] != (Move{Start: 0, End: 1<<32 - 1, From: "", To: "server1"}) {
+		t.Errorf("Expected all the hashes to move to server1, but got %+v", moves)
+	}
+
+	if moves, _ := Diff(h, h); len(moves) != 0 {
+		t.Errorf("Expected no moves between identical rings, but got %+v", moves)
+	}
+}
+
+func TestWHEN_DifferentHashFunctions_THEN_DiffFails(t *testing.T) {
+	before := &ConsistentHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	after := &ConsistentHashing{
+		HashFunc: hashfunc.CRC32,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if _, err := Diff(before, after); err == nil {
+		t.Error("Expected non-nil error as the rings use different hash functions but got nil")
+	}
+
+	after = &ConsistentHashing{
+		TokenBits: 64,
+		Logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if _, err := Diff(before, after); err == nil {
+		t.Error("Expected non-nil error as the rings use different token bits but got nil")
+	}
+}
+
+func TestWHEN_RingsHashWithAnotherSeed_THEN_DiffFails(t *testing.T) {
+	for _, hashFuncs := range seededHashFuncs {
+		before := &ConsistentHashing{Replicas: 10, HashFunc: hashFuncs[0]}
+		after := &ConsistentHashing{Replicas: 10, HashFunc: hashFuncs[1]}
+
+		if _, err := Diff(before, after); err == nil {
+			t.Errorf("Expected non-nil error as the rings use another %s but got nil", hashFuncs[0].Name())
+		}
+	}
+}
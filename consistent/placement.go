@@ -0,0 +1,135 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+// With zone and rack aware placement, the replicas of a key are spread over distinct
+// failure domains. Going clockwise onto the ring from the key, the nodes in a zone
+// not used yet are picked first, then the nodes in a rack not used yet, then the
+// nodes on a host not used yet and finally any remaining node. Losing a zone can then
+// only lose all the replicas of a key when there are fewer zones than replicas.
+
+// NodeInfo describes the failure domains of a node
+type NodeInfo struct {
+	Zone string `json:"zone,omitempty"`
+	Rack string `json:"rack,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+// SetNodeInfo set the failure domains of a node already in the ring
+func (h *ConsistentHashing) SetNodeInfo(node string, info NodeInfo) error {
+	h.logger().Info("SetNodeInfo", "node", node, "zone", info.Zone, "rack", info.Rack, "host", info.Host)
+
+	return h.update(func(r *ring) error {
+		m, ok := r.members[node]
+		if !ok {
+			h.logger().Error("SetNodeInfo unknown node", "node", node)
+			return errors.New("Expected node to be present")
+		}
+
+		updated := *m
+		updated.info = info
+		r.members[node] = &updated
+
+		return nil
+	})
+}
+
+// GetNodeInfo returns the failure domains of the given node, and whether the node
+// is in the ring
+func (h *ConsistentHashing) GetNodeInfo(node string) (NodeInfo, bool) {
+	if m, ok := h.snapshot().members[node]; ok {
+		return m.info, true
+	}
+	return NodeInfo{}, false
+}
+
+// GetPlacement will return the n distinct nodes holding the replicas of the given
+// key, spread over as many zones, racks and hosts as possible. The nodes are picked
+// going clockwise onto the ring from the key, so that without failure domains the
+// placement is the same as GetN.
+// If n is greater than the number of nodes, all the nodes are returned
+func (h *ConsistentHashing) GetPlacement(key string, n int) ([]string, error) {
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("GetPlacement", logging.KeyHash(key), "n", n)
+	}
+
+	if len(key) == 0 || n <= 0 {
+		h.logger().Error("GetPlacement failed", logging.KeyHash(key), "n", n)
+		return nil, errors.New("Expected key to be non-empty and n to be positive non 0")
+	}
+
+	r := h.snapshot()
+
+	if len(r.tokens) == 0 {
+		return []string{}, nil
+	}
+
+	idx := r.search(h.computeHash(r, key))
+
+	domains := []func(info NodeInfo) string{
+		func(info NodeInfo) string { return info.Zone },
+		func(info NodeInfo) string { return info.Zone + "/" + info.Rack },
+		func(info NodeInfo) string { return info.Zone + "/" + info.Rack + "/" + info.Host },
+		nil,
+	}
+
+	nodes := make([]string, 0, n)
+	picked := make(map[string]bool, n)
+
+	for _, domain := range domains {
+		used := make(map[string]bool)
+		if domain != nil {
+			for _, node := range nodes {
+				used[domain(r.members[node].info)] = true
+			}
+		}
+
+		for i := 0; i < len(r.tokens) && len(nodes) < n; i++ {
+			node := r.owners[(idx+i)%len(r.tokens)]
+			if picked[node] {
+				continue
+			}
+
+			if domain != nil {
+				d := domain(r.members[node].info)
+				if used[d] {
+					continue
+				}
+				used[d] = true
+			}
+
+			picked[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
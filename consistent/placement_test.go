@@ -0,0 +1,132 @@
+package consistent
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_SetNodeInfoOnUnknownNode_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := h.SetNodeInfo("unknown", NodeInfo{Zone: "az1"}); err == nil {
+		t.Error("Expected non-nil error as the node is unknown but got nil")
+	}
+
+	h.AddNode("server1")
+
+	if err := h.SetNodeInfo("server1", NodeInfo{Zone: "az1", Rack: "r1", Host: "h1"}); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	if info, ok := h.GetNodeInfo("server1"); !ok || info.Zone != "az1" || info.Rack != "r1" || info.Host != "h1" {
+		t.Errorf("Expected the node info to be set, but got %v", info)
+	}
+}
+
+func TestWHEN_EnoughZones_THEN_ReplicasInDistinctZones(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for i := 0; i < 9; i++ {
+		node := fmt.Sprintf("server%d", i)
+		h.AddNode(node)
+		h.SetNodeInfo(node, NodeInfo{Zone: fmt.Sprintf("az%d", i%3), Rack: fmt.Sprintf("r%d", i)})
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		nodes, err := h.GetPlacement(key, 3)
+		if err != nil {
+			t.Errorf("Expected no errors to occur but got %s", err)
+		}
+
+		zones := make(map[string]bool)
+		for _, node := range nodes {
+			info, _ := h.GetNodeInfo(node)
+			zones[info.Zone] = true
+		}
+
+		if len(nodes) != 3 || len(zones) != 3 {
+			t.Errorf("Expected 3 nodes in 3 distinct zones for key `%s`, but got %v", key, nodes)
+		}
+
+		if nodes[0] != h.GetImmediateNode(key) {
+			t.Errorf("Expected the first node to be the immediate node `%s`, but got `%s`", h.GetImmediateNode(key), nodes[0])
+		}
+	}
+}
+
+func TestWHEN_FewerZonesThanReplicas_THEN_FallbackToDistinctRacks(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	infos := map[string]NodeInfo{
+		"server1": {Zone: "az1", Rack: "r1", Host: "h1"},
+		"server2": {Zone: "az1", Rack: "r1", Host: "h2"},
+		"server3": {Zone: "az1", Rack: "r2", Host: "h3"},
+		"server4": {Zone: "az2", Rack: "r3", Host: "h4"},
+		"server5": {Zone: "az2", Rack: "r3", Host: "h5"},
+	}
+
+	for node, info := range infos {
+		h.AddNode(node)
+		h.SetNodeInfo(node, info)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		nodes, _ := h.GetPlacement(key, 3)
+
+		zones := make(map[string]bool)
+		racks := make(map[string]bool)
+		for _, node := range nodes {
+			zones[infos[node].Zone] = true
+			racks[infos[node].Rack] = true
+		}
+
+		if len(nodes) != 3 || len(zones) != 2 || len(racks) != 3 {
+			t.Errorf("Expected 3 nodes in 2 zones and 3 racks for key `%s`, but got %v", key, nodes)
+		}
+	}
+
+	nodes, _ := h.GetPlacement("hello", 10)
+	if len(nodes) != 5 {
+		t.Errorf("Expected all the 5 nodes to be returned, but got %v", nodes)
+	}
+}
+
+func TestWHEN_NoNodeInfo_THEN_PlacementIsPreferenceList(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+	h.AddNode("server4")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		expected, _ := h.GetN(key, 3)
+		actual, _ := h.GetPlacement(key, 3)
+
+		if fmt.Sprint(expected) != fmt.Sprint(actual) {
+			t.Errorf("Expected placement %v for key `%s`, but got %v", expected, key, actual)
+		}
+	}
+
+	if _, err := h.GetPlacement("hello", 0); err == nil {
+		t.Error("Expected non-nil error as n is 0 but got nil")
+	}
+}
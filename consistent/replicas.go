@@ -0,0 +1,141 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Changing the replicas re-tokenizes every node of the ring in a single change of
+// the ring. As the tokens of a node are derived from its name and the index of the
+// virtual node, each node keeps the tokens of its first virtual nodes and only the
+// tokens of the virtual nodes added or removed by the change move, exactly as if
+// the weight of every node was updated at once. The explicit tokens of a node are
+// left in place.
+
+// A vtoken is a token derived from a virtual node of the given node
+type vtoken struct {
+	token uint64
+	node  string
+}
+
+// SetReplicas set the replicas for the entities to be hashed in the ring, and
+// re-tokenizes the nodes already in the ring in a single change of the ring, so
+// that RemoveNode always finds the tokens of a node. The ranges of hashes which
+// moved are reported by Moves. The number of replicas must be positive non 0, and
+// give at least one virtual node to every node with derived tokens
+func (h *ConsistentHashing) SetReplicas(replicas int) error {
+	if replicas <= 0 {
+		h.logger().Error("SetReplicas failed", "replicas", replicas)
+		return errors.New("Expected replicas to be positive non 0")
+	}
+
+	return h.update(func(r *ring) error {
+		before := h.current()
+
+		previous := h.Replicas
+		h.Replicas = replicas
+
+		if err := h.retokenize(r); err != nil {
+			h.Replicas = previous
+			return err
+		}
+		r.moves = diffRings(before, r)
+
+		h.logger().Info("SetReplicas", "replicas", replicas, "moves", len(r.moves))
+
+		return nil
+	})
+}
+
+// Moves returns the ranges of hashes whose owner changed during SetReplicas, ordered
+// by hash, when SetReplicas was the last change of the ring. Any other change of the
+// ring clears them
+func (h *ConsistentHashing) Moves() []Move {
+	return append([]Move(nil), h.snapshot().moves...)
+}
+
+// Private function not exported to be able to change the number of virtual nodes of
+// every node to the number of virtual nodes for its weight. The tokens of the
+// removed virtual nodes are filtered out in a single pass, then the tokens of the
+// added virtual nodes are merged at once. The ring is left unchanged when the weight
+// of a node gives it no virtual node
+func (h *ConsistentHashing) retokenize(r *ring) error {
+	nodes := make([]string, 0, len(r.members))
+	for node := range r.members {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if m := r.members[node]; m.tokens == nil && h.computeReplicas(m.weight) == 0 {
+			h.logger().Error("SetReplicas weight without virtual nodes", "node", node, "weight", m.weight, "replicas", h.Replicas)
+			return fmt.Errorf("Expected weight of node %q to give at least one virtual node", node)
+		}
+	}
+
+	removed := make(map[vtoken]bool)
+
+	var added []vnode
+
+	for n, node := range nodes {
+		m := r.members[node]
+		replicas := h.computeReplicas(m.weight)
+
+		if m.tokens != nil || replicas == m.replicas {
+			continue
+		}
+
+		for i := m.replicas; i < replicas; i++ {
+			added = append(added, vnode{token: h.computeHash(r, node+strconv.Itoa(i)), node: n})
+		}
+
+		if replicas < m.replicas {
+			for i := replicas; i < m.replicas; i++ {
+				removed[vtoken{h.computeHash(r, node+strconv.Itoa(i)), node}] = true
+			}
+
+			// a token also derived from a virtual node which is kept stays in the ring
+			for i := 0; i < replicas; i++ {
+				delete(removed, vtoken{h.computeHash(r, node+strconv.Itoa(i)), node})
+			}
+		}
+
+		updated := *m
+		updated.replicas = replicas
+		r.members[node] = &updated
+	}
+
+	if len(removed) > 0 {
+		h.removeVnodes(r, func(token uint64, node string) bool {
+			return removed[vtoken{token, node}]
+		})
+	}
+
+	h.insertVnodes(r, added, nodes)
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package consistent
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+func TestWHEN_SetReplicasOnNonEmptyRing_THEN_SameRingAsAddingNodesWithNewReplicas(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNodes([]string{"server1", "server2", "server3"})
+	h.AddWeightedNode("big-box", 2)
+
+	for _, replicas := range []int{25, 5, 10} {
+		if err := h.SetReplicas(replicas); err != nil {
+			t.Fatalf("Expected no errors to occur but got %s", err)
+		}
+
+		expected := &ConsistentHashing{Replicas: replicas}
+		expected.AddNodes([]string{"server1", "server2", "server3"})
+		expected.AddWeightedNode("big-box", 2)
+
+		if !reflect.DeepEqual(owners(h), owners(expected)) {
+			t.Errorf("Expected the ring with %d replicas to be re-tokenized, but got %d tokens", replicas, len(h.Tokens()))
+		}
+	}
+
+	h.RemoveNode("big-box")
+
+	for _, node := range owners(h) {
+		if node == "big-box" {
+			t.Fatal("Expected no token to be left in the ring after RemoveNode")
+		}
+	}
+}
+
+func TestWHEN_SetReplicas_THEN_OnlyTokensOfChangedVirtualNodesMove(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 20,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: hashfunc.XXHash64,
+	}
+	h.AddNodes([]string{"server1", "server2", "server3"})
+
+	before := cloneRing(h)
+	h.SetReplicas(40)
+
+	for token, node := range owners(before) {
+		if owners(h)[token] != node {
+			t.Errorf("Expected token %d to stay owned by `%s`, but got `%s`", token, node, owners(h)[token])
+		}
+	}
+
+	expected, _ := Diff(before, h)
+	if moves := h.Moves(); len(moves) == 0 || !reflect.DeepEqual(moves, expected) {
+		t.Errorf("Expected the moves to be the diff of the rings, but got %v and %v", moves, expected)
+	}
+
+	h.AddNode("server4")
+
+	if moves := h.Moves(); moves != nil {
+		t.Errorf("Expected AddNode to clear the moves of SetReplicas, but got %v", moves)
+	}
+}
+
+func TestWHEN_SetReplicasDropsOwnerOfCollision_THEN_CollidingNodeClaimsToken(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 2,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: firstVnodeHashFunc{},
+	}
+	h.AddNode("server1")
+	h.AddWeightedNode("server2", 2)
+
+	if owners(h)[42] != "server1" || len(h.Collisions()) != 3 {
+		t.Fatalf("Expected `server2` to collide with `server1`, but got %v", h.Collisions())
+	}
+
+	// server1 drops its second virtual node, at token 42, which server2 keeps
+	h.SetReplicas(1)
+
+	if owners(h)[42] != "server2" || len(h.Collisions()) != 0 {
+		t.Errorf("Expected `server2` to claim token 42, but got `%s` and %v", owners(h)[42], h.Collisions())
+	}
+
+	h.SetReplicas(2)
+
+	collisions := h.Collisions()
+	expected := Collision{Token: 42, Node: "server1", Owner: "server2"}
+	if owners(h)[42] != "server2" || len(collisions) != 1 || collisions[0] != expected {
+		t.Errorf("Expected `server2` to keep token 42, but got %v", collisions)
+	}
+}
+
+func TestWHEN_SetReplicasLeavesNodeWithoutVirtualNodes_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 3,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNode("server1")
+	h.AddWeightedNode("small", 0.4)
+
+	if err := h.SetReplicas(1); err == nil {
+		t.Error("Expected non-nil error as `small` would have no virtual node but got nil")
+	}
+
+	if h.Replicas != 3 || len(h.NodeTokens("small")) != 1 || len(h.Tokens()) != 4 {
+		t.Errorf("Expected the ring to be unchanged, but got %d replicas and %d tokens", h.Replicas, len(h.Tokens()))
+	}
+
+	if err := h.SetReplicas(5); err != nil || len(h.NodeTokens("small")) != 2 {
+		t.Errorf("Expected `small` to have 2 virtual nodes, but got %d and %v", len(h.NodeTokens("small")), err)
+	}
+}
+
+func TestWHEN_SetReplicasNegative_THEN_RingUnchanged(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNode("server1")
+
+	if err := h.SetReplicas(-1); err == nil {
+		t.Error("Expected non-nil error as replicas is negative but got nil")
+	}
+
+	if err := h.SetReplicas(0); err == nil {
+		t.Error("Expected non-nil error as replicas is 0 but got nil")
+	}
+
+	if len(h.Tokens()) != 10 || h.Replicas != 10 {
+		t.Errorf("Expected the ring to keep 10 tokens, but got %d", len(h.Tokens()))
+	}
+}
@@ -0,0 +1,549 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// A snapshot is the exact state of the ring : its configuration, its nodes and the
+// owner of every token. A ring restored from a snapshot owns the keys exactly as the
+// ring the snapshot was taken from, whatever the order in which its nodes were added.
+// Snapshots are written as JSON with encoding/json, or in a compact versioned binary
+// format with MarshalBinary. The loads of the nodes are NOT part of the snapshot.
+
+// SnapshotVersion is the version of the snapshots written by this package
+const SnapshotVersion = 1
+
+// snapshotMagic starts every binary snapshot
+var snapshotMagic = []byte("HPCR")
+
+// fingerprintProbe is hashed by the hash function of the ring to tell apart the hash
+// functions sharing a name, such as MurmurHash3 with different seeds
+var fingerprintProbe = []byte("hasherprovider/consistent")
+
+// Snapshot is the serializable state of a ring. The nodes are sorted by name and the
+// tokens are sorted. Fingerprint is the hash of a fixed probe by the hash function,
+// so that a ring hashing with another seed or key can NOT restore the snapshot
+type Snapshot struct {
+	Version     int             `json:"version"`
+	HashFunc    string          `json:"hash_func"`
+	Fingerprint uint64          `json:"fingerprint"`
+	TokenBits   int             `json:"token_bits"`
+	Replicas    int             `json:"replicas"`
+	LoadFactor  float64         `json:"load_factor,omitempty"`
+	Nodes       []NodeSnapshot  `json:"nodes"`
+	Tokens      []TokenSnapshot `json:"tokens"`
+	Collisions  []Collision     `json:"collisions,omitempty"`
+}
+
+// NodeSnapshot is the serializable state of a node of the ring. Tokens are the
+// explicit tokens of the node, empty when its tokens are derived from its name
+type NodeSnapshot struct {
+	Name     string   `json:"name"`
+	Weight   float64  `json:"weight"`
+	Replicas int      `json:"replicas"`
+	Info     NodeInfo `json:"info"`
+	Tokens   []uint64 `json:"tokens,omitempty"`
+}
+
+// TokenSnapshot is a token of the ring and the node owning it
+type TokenSnapshot struct {
+	Token uint64 `json:"token"`
+	Node  string `json:"node"`
+}
+
+// Snapshot returns the current state of the ring
+func (h *ConsistentHashing) Snapshot() *Snapshot {
+	h.mu.Lock()
+	r := h.current()
+	replicas := h.Replicas
+	h.mu.Unlock()
+
+	s := &Snapshot{
+		Version:     SnapshotVersion,
+		HashFunc:    h.hashFuncName(r.tokenBits),
+		Fingerprint: h.fingerprint(r.tokenBits),
+		TokenBits:   r.tokenBits,
+		Replicas:    replicas,
+		LoadFactor:  r.loadFactor,
+		Nodes:       make([]NodeSnapshot, 0, len(r.members)),
+		Tokens:      make([]TokenSnapshot, len(r.tokens)),
+		Collisions:  append([]Collision(nil), r.collisions...),
+	}
+
+	if s.TokenBits == 0 {
+		s.TokenBits = 32
+	}
+
+	for node, m := range r.members {
+		s.Nodes = append(s.Nodes, NodeSnapshot{
+			Name:     node,
+			Weight:   m.weight,
+			Replicas: m.replicas,
+			Info:     m.info,
+			Tokens:   slices.Clone(m.tokens),
+		})
+	}
+
+	sort.Slice(s.Nodes, func(i, j int) bool {
+		return s.Nodes[i].Name < s.Nodes[j].Name
+	})
+
+	for i, token := range r.tokens {
+		s.Tokens[i] = TokenSnapshot{Token: token, Node: r.owners[i]}
+	}
+
+	return s
+}
+
+// Restore replaces the ring with the ring of the given snapshot. The hash function of
+// the hasher must have the name of the hash function of the snapshot, so that the
+// keys are hashed as in the ring the snapshot was taken from. The loads of the nodes
+// still in the ring are kept
+func (h *ConsistentHashing) Restore(s *Snapshot) error {
+	if err := h.validateSnapshot(s); err != nil {
+		h.logger().Error("Restore failed", "error", err)
+		return err
+	}
+
+	h.logger().Info("Restore", "nodes", len(s.Nodes), "tokens", len(s.Tokens))
+
+	return h.update(func(r *ring) error {
+		members := make(map[string]*member, len(s.Nodes))
+
+		for _, node := range s.Nodes {
+			var tokens []uint64
+			if len(node.Tokens) > 0 {
+				tokens = slices.Clone(node.Tokens)
+			}
+
+			load := new(atomic.Int64)
+			if m, ok := r.members[node.Name]; ok {
+				load = m.load
+			}
+
+			members[node.Name] = &member{
+				weight:   node.Weight,
+				replicas: node.Replicas,
+				info:     node.Info,
+				load:     load,
+				tokens:   tokens,
+			}
+		}
+
+		r.members = members
+		r.tokens = make([]uint64, len(s.Tokens))
+		r.owners = make([]string, len(s.Tokens))
+		r.collisions = append([]Collision(nil), s.Collisions...)
+		r.tokenBits = s.TokenBits
+		r.loadFactor = s.LoadFactor
+
+		for i, token := range s.Tokens {
+			r.tokens[i] = token.Token
+			r.owners[i] = token.Node
+		}
+
+		h.Replicas = s.Replicas
+		h.TokenBits = s.TokenBits
+		h.LoadFactor = s.LoadFactor
+
+		return nil
+	})
+}
+
+// Private function not exported checking the snapshot describes a valid ring which
+// can be restored with the hash function of the hasher
+func (h *ConsistentHashing) validateSnapshot(s *Snapshot) error {
+	if s == nil {
+		return errors.New("Expected snapshot to be non-nil")
+	}
+
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("Expected snapshot version %d, but got %d", SnapshotVersion, s.Version)
+	}
+
+	if s.TokenBits != 32 && s.TokenBits != 64 {
+		return fmt.Errorf("Expected token bits to be 32 or 64, but got %d", s.TokenBits)
+	}
+
+	if name := h.hashFuncName(s.TokenBits); s.HashFunc != name {
+		return fmt.Errorf("Expected hash function %s, but the snapshot was taken with %s", name, s.HashFunc)
+	}
+
+	if s.Fingerprint != h.fingerprint(s.TokenBits) {
+		return fmt.Errorf("Expected hash function %s with the seed or key of the hasher, but the snapshot was taken with another one", s.HashFunc)
+	}
+
+	if s.Replicas < 0 || (s.LoadFactor != 0 && s.LoadFactor < 1) || math.IsNaN(s.LoadFactor) || math.IsInf(s.LoadFactor, 0) {
+		return errors.New("Expected replicas to be positive and load factor to be 0 or greater or equal to 1")
+	}
+
+	nodes := make(map[string]bool, len(s.Nodes))
+	for _, node := range s.Nodes {
+		if len(node.Name) == 0 || nodes[node.Name] {
+			return fmt.Errorf("Expected nodes to be non-empty and distinct, but got %q", node.Name)
+		}
+
+		if node.Weight <= 0 || math.IsNaN(node.Weight) || math.IsInf(node.Weight, 0) || node.Replicas < 0 {
+			return fmt.Errorf("Expected node %q to have a positive weight and replicas", node.Name)
+		}
+
+		nodes[node.Name] = true
+	}
+
+	owners := make(map[uint64]string, len(s.Tokens))
+	for i, token := range s.Tokens {
+		owners[token.Token] = token.Node
+
+		if !nodes[token.Node] {
+			return fmt.Errorf("Expected token %d to be owned by a node of the snapshot, but got %q", token.Token, token.Node)
+		}
+
+		if i > 0 && s.Tokens[i-1].Token >= token.Token {
+			return errors.New("Expected tokens to be sorted and distinct")
+		}
+
+		if s.TokenBits == 32 && token.Token > math.MaxUint32 {
+			return fmt.Errorf("Expected token %d to fit in 32 bits", token.Token)
+		}
+	}
+
+	for _, collision := range s.Collisions {
+		if !nodes[collision.Node] || !nodes[collision.Owner] {
+			return fmt.Errorf("Expected collision of token %d to be between nodes of the snapshot", collision.Token)
+		}
+	}
+
+	for _, node := range s.Nodes {
+		for i, token := range node.Tokens {
+			if owners[token] != node.Name || (i > 0 && node.Tokens[i-1] >= token) {
+				return fmt.Errorf("Expected the explicit tokens of %q to be sorted and owned by the node", node.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Private function not exported returning the hash function used for the given size
+// of the tokens
+func (h *ConsistentHashing) hashFunc(tokenBits int) hashfunc.HashFunc {
+	switch {
+	case h.HashFunc != nil:
+		return h.HashFunc
+	case tokenBits == 64:
+		return hashfunc.FNV1a64
+	default:
+		return hashfunc.FNV1a32
+	}
+}
+
+// Private function not exported returning the name of the hash function used for
+// the given size of the tokens
+func (h *ConsistentHashing) hashFuncName(tokenBits int) string {
+	return h.hashFunc(tokenBits).Name()
+}
+
+// Private function not exported returning the fingerprint of the hash function used
+// for the given size of the tokens, which differs with the seed or the key of the
+// hash function
+func (h *ConsistentHashing) fingerprint(tokenBits int) uint64 {
+	return h.hashFunc(tokenBits).Sum64(fingerprintProbe)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The snapshot is written as the
+// magic "HPCR" followed by its version, its hash function and its fingerprint, its
+// configuration, its nodes, its tokens as deltas from the previous token, each
+// followed by the index of its node, and its collisions. The explicit tokens of a
+// node follow its failure domains, as deltas. Integers are written as varints and
+// floats as 8 bytes little endian
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("Expected snapshot version %d, but got %d", SnapshotVersion, s.Version)
+	}
+
+	indexes := make(map[string]uint64, len(s.Nodes))
+	for i, node := range s.Nodes {
+		indexes[node.Name] = uint64(i)
+	}
+
+	index := func(node string) (uint64, error) {
+		i, ok := indexes[node]
+		if !ok {
+			return 0, fmt.Errorf("Expected %q to be a node of the snapshot", node)
+		}
+		return i, nil
+	}
+
+	w := &snapshotWriter{}
+	w.buf.Write(snapshotMagic)
+	w.uvarint(uint64(s.Version))
+	w.string(s.HashFunc)
+	w.uvarint(s.Fingerprint)
+	w.uvarint(uint64(s.TokenBits))
+	w.uvarint(uint64(s.Replicas))
+	w.float(s.LoadFactor)
+
+	w.uvarint(uint64(len(s.Nodes)))
+	for _, node := range s.Nodes {
+		w.string(node.Name)
+		w.float(node.Weight)
+		w.uvarint(uint64(node.Replicas))
+		w.string(node.Info.Zone)
+		w.string(node.Info.Rack)
+		w.string(node.Info.Host)
+
+		w.uvarint(uint64(len(node.Tokens)))
+		previous := uint64(0)
+		for _, token := range node.Tokens {
+			if token < previous {
+				return nil, errors.New("Expected explicit tokens to be sorted")
+			}
+
+			w.uvarint(token - previous)
+			previous = token
+		}
+	}
+
+	w.uvarint(uint64(len(s.Tokens)))
+	previous := uint64(0)
+	for _, token := range s.Tokens {
+		i, err := index(token.Node)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Token < previous {
+			return nil, errors.New("Expected tokens to be sorted")
+		}
+
+		w.uvarint(token.Token - previous)
+		w.uvarint(i)
+		previous = token.Token
+	}
+
+	w.uvarint(uint64(len(s.Collisions)))
+	for _, collision := range s.Collisions {
+		node, err := index(collision.Node)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err := index(collision.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		w.uvarint(collision.Token)
+		w.uvarint(node)
+		w.uvarint(owner)
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, reading a snapshot written
+// by MarshalBinary
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, snapshotMagic) {
+		return errors.New("Expected binary snapshot to start with HPCR")
+	}
+
+	r := &snapshotReader{data: data[len(snapshotMagic):]}
+
+	var decoded Snapshot
+
+	version := int(r.uvarint())
+	if r.err == nil && version != SnapshotVersion {
+		return fmt.Errorf("Expected snapshot version %d, but got %d", SnapshotVersion, version)
+	}
+	decoded.Version = version
+
+	decoded.HashFunc = r.string()
+	decoded.Fingerprint = r.uvarint()
+	decoded.TokenBits = int(r.uvarint())
+	decoded.Replicas = int(r.uvarint())
+	decoded.LoadFactor = r.float()
+
+	nodes := r.count()
+	decoded.Nodes = make([]NodeSnapshot, 0, nodes)
+	for i := 0; i < nodes && r.err == nil; i++ {
+		node := NodeSnapshot{
+			Name:     r.string(),
+			Weight:   r.float(),
+			Replicas: int(r.uvarint()),
+			Info:     NodeInfo{Zone: r.string(), Rack: r.string(), Host: r.string()},
+		}
+
+		if count := r.count(); count > 0 {
+			node.Tokens = make([]uint64, 0, count)
+			previous := uint64(0)
+			for j := 0; j < count && r.err == nil; j++ {
+				previous += r.uvarint()
+				node.Tokens = append(node.Tokens, previous)
+			}
+		}
+
+		decoded.Nodes = append(decoded.Nodes, node)
+	}
+
+	tokens := r.count()
+	decoded.Tokens = make([]TokenSnapshot, 0, tokens)
+	previous := uint64(0)
+	for i := 0; i < tokens && r.err == nil; i++ {
+		previous += r.uvarint()
+		decoded.Tokens = append(decoded.Tokens, TokenSnapshot{Token: previous, Node: r.node(decoded.Nodes)})
+	}
+
+	if collisions := r.count(); collisions > 0 {
+		decoded.Collisions = make([]Collision, 0, collisions)
+		for i := 0; i < collisions && r.err == nil; i++ {
+			decoded.Collisions = append(decoded.Collisions, Collision{
+				Token: r.uvarint(),
+				Node:  r.node(decoded.Nodes),
+				Owner: r.node(decoded.Nodes),
+			})
+		}
+	}
+
+	if r.err == nil && len(r.data) > 0 {
+		r.err = errors.New("Expected binary snapshot to end after its collisions")
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	*s = decoded
+
+	return nil
+}
+
+type snapshotWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *snapshotWriter) uvarint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *snapshotWriter) float(f float64) {
+	w.buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func (w *snapshotWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// A snapshotReader reads the binary snapshot, and keeps the first error. Once an
+// error occurred, every read returns a zero value
+type snapshotReader struct {
+	data []byte
+	err  error
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("Expected a varint in the binary snapshot")
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *snapshotReader) float() float64 {
+	if r.err == nil && len(r.data) < 8 {
+		r.err = errors.New("Expected a float in the binary snapshot")
+	}
+
+	if r.err != nil {
+		return 0
+	}
+
+	f := math.Float64frombits(binary.LittleEndian.Uint64(r.data))
+	r.data = r.data[8:]
+
+	return f
+}
+
+func (r *snapshotReader) string() string {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(len(r.data)) {
+		r.err = errors.New("Expected a string in the binary snapshot")
+	}
+
+	if r.err != nil {
+		return ""
+	}
+
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+
+	return s
+}
+
+// count reads the number of elements of a list, which can not be greater than the
+// remaining bytes since every element takes at least one byte
+func (r *snapshotReader) count() int {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(len(r.data)) {
+		r.err = errors.New("Expected a valid count in the binary snapshot")
+	}
+
+	if r.err != nil {
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *snapshotReader) node(nodes []NodeSnapshot) string {
+	i := r.uvarint()
+	if r.err == nil && i >= uint64(len(nodes)) {
+		r.err = errors.New("Expected a valid node index in the binary snapshot")
+	}
+
+	if r.err != nil {
+		return ""
+	}
+
+	return nodes[i].Name
+}
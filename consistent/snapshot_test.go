@@ -0,0 +1,298 @@
+package consistent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// Private function not exported building a ring with weights, failure domains and
+// collisions, adding its nodes in the given order
+func newSnapshotRing(order []string) *ConsistentHashing {
+	h := &ConsistentHashing{
+		Replicas: 50,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, node := range order {
+		if node == "big-box" {
+			h.AddWeightedNode(node, 2.5)
+		} else {
+			h.AddNode(node)
+		}
+		h.SetNodeInfo(node, NodeInfo{Zone: "az-" + node, Rack: "r1"})
+	}
+
+	return h
+}
+
+func TestWHEN_NodesAddedInDifferentOrder_THEN_SnapshotsIdentical(t *testing.T) {
+	first := newSnapshotRing([]string{"server1", "big-box", "server2", "server3"})
+	second := newSnapshotRing([]string{"server3", "server2", "big-box", "server1"})
+
+	// the owner of a colliding token depends on the order, hence a ring without collisions
+	if len(first.Collisions()) != 0 {
+		t.Fatalf("Expected no collisions, but got %v", first.Collisions())
+	}
+
+	a, _ := json.Marshal(first.Snapshot())
+	b, _ := json.Marshal(second.Snapshot())
+
+	if !bytes.Equal(a, b) {
+		t.Errorf("Expected identical JSON snapshots, but got %s and %s", a, b)
+	}
+
+	a, _ = first.Snapshot().MarshalBinary()
+	b, _ = second.Snapshot().MarshalBinary()
+
+	if !bytes.Equal(a, b) {
+		t.Error("Expected identical binary snapshots")
+	}
+}
+
+func TestWHEN_SnapshotRestoredFromJSON_THEN_RingIdentical(t *testing.T) {
+	h := newSnapshotRing([]string{"server1", "big-box", "server2", "server3"})
+
+	data, err := json.Marshal(h.Snapshot())
+	if err != nil {
+		t.Fatalf("Unexpected error writing the snapshot: %v", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Unexpected error reading the snapshot: %v", err)
+	}
+
+	restored := &ConsistentHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := restored.Restore(&snapshot); err != nil {
+		t.Fatalf("Unexpected error restoring the snapshot: %v", err)
+	}
+
+	assertSameRing(t, h, restored)
+}
+
+func TestWHEN_SnapshotRestoredFromBinary_THEN_RingIdentical(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas:  100,
+		TokenBits: 64,
+		HashFunc:  hashfunc.XXHash64,
+		Logger:    slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for i := 0; i < 20; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+	h.UpdateWeight("server3", 0.5)
+	h.SetLoadFactor(1.25)
+
+	data, err := h.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Unexpected error writing the snapshot: %v", err)
+	}
+
+	if text, _ := json.Marshal(h.Snapshot()); len(data) > len(text)/2 {
+		t.Errorf("Expected a compact binary snapshot, but got %d bytes for %d bytes of JSON", len(data), len(text))
+	}
+
+	var snapshot Snapshot
+	if err := snapshot.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Unexpected error reading the snapshot: %v", err)
+	}
+
+	restored := &ConsistentHashing{
+		HashFunc: hashfunc.XXHash64,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := restored.Restore(&snapshot); err != nil {
+		t.Fatalf("Unexpected error restoring the snapshot: %v", err)
+	}
+
+	assertSameRing(t, h, restored)
+
+	// nodes are then added as to the original ring
+	h.AddNode("server20")
+	restored.AddNode("server20")
+
+	assertSameRing(t, h, restored)
+}
+
+func TestWHEN_RingOfExplicitTokensRestored_THEN_RingIdentical(t *testing.T) {
+	// a ring of explicit tokens only, without replicas
+	h := &ConsistentHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNodeWithTokens("server1", []uint64{10, 20})
+	h.AddNodeWithTokens("server2", []uint64{15})
+
+	text, err := json.Marshal(h.Snapshot())
+	if err != nil {
+		t.Fatalf("Unexpected error writing the snapshot: %v", err)
+	}
+
+	data, err := h.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Unexpected error writing the snapshot: %v", err)
+	}
+
+	var fromJSON, fromBinary Snapshot
+	if err := json.Unmarshal(text, &fromJSON); err != nil {
+		t.Fatalf("Unexpected error reading the snapshot: %v", err)
+	}
+	if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Unexpected error reading the snapshot: %v", err)
+	}
+
+	for _, snapshot := range []*Snapshot{&fromJSON, &fromBinary} {
+		restored := &ConsistentHashing{
+			Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		}
+
+		if err := restored.Restore(snapshot); err != nil {
+			t.Fatalf("Unexpected error restoring the snapshot: %v", err)
+		}
+
+		assertSameRing(t, h, restored)
+	}
+
+	if err := (&ConsistentHashing{}).Restore((&ConsistentHashing{}).Snapshot()); err != nil {
+		t.Errorf("Expected the snapshot of an empty ring to be restored, but got %v", err)
+	}
+}
+
+func TestWHEN_InvalidSnapshot_THEN_RestoreFails(t *testing.T) {
+	h := newSnapshotRing([]string{"server1", "server2"})
+
+	invalid := map[string]func(s *Snapshot){
+		"version":       func(s *Snapshot) { s.Version = SnapshotVersion + 1 },
+		"hash function": func(s *Snapshot) { s.HashFunc = "xxhash64" },
+		"token bits":    func(s *Snapshot) { s.TokenBits = 16 },
+		"unknown owner": func(s *Snapshot) { s.Tokens[0].Node = "server3" },
+		"unsorted":      func(s *Snapshot) { s.Tokens[0], s.Tokens[1] = s.Tokens[1], s.Tokens[0] },
+		"duplicate":     func(s *Snapshot) { s.Nodes[1].Name = s.Nodes[0].Name },
+		"weight":        func(s *Snapshot) { s.Nodes[0].Weight = 0 },
+		"replicas":      func(s *Snapshot) { s.Replicas = -1 },
+	}
+
+	for name, corrupt := range invalid {
+		snapshot := h.Snapshot()
+		corrupt(snapshot)
+
+		restored := &ConsistentHashing{
+			Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		}
+
+		if err := restored.Restore(snapshot); err == nil {
+			t.Errorf("Expected non-nil error as the %s of the snapshot is invalid but got nil", name)
+		}
+
+		if len(restored.Tokens()) != 0 {
+			t.Errorf("Expected the ring to be unchanged when the %s of the snapshot is invalid", name)
+		}
+	}
+}
+
+func TestWHEN_BinarySnapshotCorrupted_THEN_ErrorReturned(t *testing.T) {
+	h := newSnapshotRing([]string{"server1", "server2"})
+
+	data, _ := h.Snapshot().MarshalBinary()
+
+	for _, corrupted := range [][]byte{
+		nil,
+		[]byte("JSON"),
+		data[:len(data)/2],
+		data[:len(data)-1],
+		append(append([]byte(nil), data...), 0),
+	} {
+		var snapshot Snapshot
+		if err := snapshot.UnmarshalBinary(corrupted); err == nil {
+			t.Errorf("Expected non-nil error as the binary snapshot of %d bytes is corrupted but got nil", len(corrupted))
+		}
+	}
+}
+
+// Private function not exported checking both rings own the keys identically and
+// have the same snapshot
+func assertSameRing(t *testing.T, expected *ConsistentHashing, actual *ConsistentHashing) {
+	t.Helper()
+
+	if !reflect.DeepEqual(expected.Snapshot(), actual.Snapshot()) {
+		t.Errorf("Expected identical snapshots, but got %+v and %+v", expected.Snapshot(), actual.Snapshot())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		a, _ := expected.Hash(key, 0)
+		b, _ := actual.Hash(key, 0)
+
+		if a != b {
+			t.Errorf("Expected key `%s` to be owned by `%s`, but got `%s`", key, a, b)
+		}
+	}
+}
+
+// Hash functions of the same name, with different seeds or keys
+var seededHashFuncs = [][2]hashfunc.HashFunc{
+	{hashfunc.NewMurmur3(1), hashfunc.NewMurmur3(2)},
+	{hashfunc.NewXXHash64(1), hashfunc.NewXXHash64(2)},
+	{hashfunc.NewSipHash([16]byte{1}), hashfunc.NewSipHash([16]byte{2})},
+}
+
+func TestWHEN_SnapshotTakenWithAnotherSeed_THEN_RestoreFails(t *testing.T) {
+	for _, hashFuncs := range seededHashFuncs {
+		h := &ConsistentHashing{
+			Replicas: 10,
+			Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			HashFunc: hashFuncs[0],
+		}
+		h.AddNode("server1")
+
+		data, _ := h.Snapshot().MarshalBinary()
+
+		var s Snapshot
+		if err := s.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Expected no errors to occur but got %s", err)
+		}
+
+		other := &ConsistentHashing{Logger: h.Logger, HashFunc: hashFuncs[1]}
+		if err := other.Restore(&s); err == nil {
+			t.Errorf("Expected non-nil error as the snapshot was taken with another %s but got nil", s.HashFunc)
+		}
+
+		same := &ConsistentHashing{Logger: h.Logger, HashFunc: hashFuncs[0]}
+		if err := same.Restore(&s); err != nil {
+			t.Errorf("Expected no errors to occur but got %s", err)
+		}
+	}
+}
+
+func TestWHEN_SnapshotWithoutFingerprint_THEN_RestoreFails(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNode("server1")
+
+	data, _ := json.Marshal(h.Snapshot())
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Expected no errors to occur but got %s", err)
+	}
+	s.Fingerprint = 0
+
+	other := &ConsistentHashing{Logger: h.Logger}
+	if err := other.Restore(&s); err == nil {
+		t.Error("Expected non-nil error as the snapshot has no fingerprint but got nil")
+	}
+}
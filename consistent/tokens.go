@@ -0,0 +1,98 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync/atomic"
+)
+
+// Besides the tokens derived from the name of a node, a node can own an explicit
+// list of tokens, as the vnodes of Cassandra. Explicit tokens mirror the token
+// layout of an existing cluster, split a hot range by inserting a token in its
+// middle, or come from an allocation algorithm balancing the ownership of the nodes
+// instead of relying on the hash of the virtual nodes. The explicit tokens of a node
+// are never derived again : changing the replicas leaves them in place.
+
+// AddNodeWithTokens will add a node owning exactly the given tokens. The tokens must
+// be distinct, fit in the size of the tokens of the ring, and NOT be owned by
+// another node. The node has a weight of 1, which can NOT be changed by UpdateWeight
+func (h *ConsistentHashing) AddNodeWithTokens(node string, tokens []uint64) error {
+	h.logger().Info("AddNodeWithTokens", "node", node, "tokens", len(tokens))
+
+	if len(node) == 0 || len(tokens) == 0 {
+		h.logger().Error("AddNodeWithTokens failed", "node", node, "tokens", len(tokens))
+		return errors.New("Expected node and tokens to be non-empty")
+	}
+
+	sorted := slices.Clone(tokens)
+	slices.Sort(sorted)
+
+	if len(slices.Compact(slices.Clone(sorted))) != len(sorted) {
+		h.logger().Error("AddNodeWithTokens duplicate tokens", "node", node)
+		return errors.New("Expected tokens to be distinct")
+	}
+
+	return h.update(func(r *ring) error {
+		if _, ok := r.members[node]; ok {
+			h.logger().Error("AddNodeWithTokens node already present", "node", node)
+			return errors.New("Expected node to not be present")
+		}
+
+		if last := sorted[len(sorted)-1]; r.tokenBits != 64 && last > math.MaxUint32 {
+			h.logger().Error("AddNodeWithTokens token too large", "node", node, "token", last)
+			return fmt.Errorf("Expected token %d to fit in 32 bits", last)
+		}
+
+		for _, token := range sorted {
+			if idx := r.search(token); idx < len(r.tokens) && r.tokens[idx] == token {
+				h.logger().Error("AddNodeWithTokens token already owned", "node", node, "token", token, "owner", r.owners[idx])
+				return fmt.Errorf("Expected token %d to not be owned, but it is owned by %q", token, r.owners[idx])
+			}
+		}
+
+		r.members[node] = &member{weight: 1, replicas: len(sorted), tokens: sorted, load: new(atomic.Int64)}
+		r.insert(slices.Clone(sorted), node)
+
+		return nil
+	})
+}
+
+// NodeTokens returns the sorted tokens owned by the given node, explicit or derived
+// from its name. The tokens of the node which collided with another node are NOT
+// owned by the node
+func (h *ConsistentHashing) NodeTokens(node string) []uint64 {
+	r := h.snapshot()
+
+	var tokens []uint64
+	for i, owner := range r.owners {
+		if owner == node {
+			tokens = append(tokens, r.tokens[i])
+		}
+	}
+
+	return tokens
+}
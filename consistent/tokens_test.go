@@ -0,0 +1,154 @@
+package consistent
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWHEN_AddNodeWithTokens_THEN_NodeOwnsExactlyItsTokens(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := h.AddNodeWithTokens("server1", []uint64{3000, 1000, 2000}); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+	h.AddNodeWithTokens("server2", []uint64{1500})
+
+	if tokens := h.NodeTokens("server1"); !reflect.DeepEqual(tokens, []uint64{1000, 2000, 3000}) {
+		t.Errorf("Expected `server1` to own tokens 1000, 2000 and 3000, but got %v", tokens)
+	}
+
+	if tokens := h.NodeTokens("server2"); !reflect.DeepEqual(tokens, []uint64{1500}) {
+		t.Errorf("Expected `server2` to own token 1500, but got %v", tokens)
+	}
+
+	if owner, _ := h.Owner(2000); owner != "server1" || len(h.Tokens()) != 4 {
+		t.Errorf("Expected token 2000 to be owned by `server1`, but got `%s`", owner)
+	}
+
+	h.AddNode("server3")
+
+	if len(h.NodeTokens("server3")) != 10 || h.NodeTokens("unknown") != nil {
+		t.Errorf("Expected `server3` to own its 10 derived tokens, but got %v", h.NodeTokens("server3"))
+	}
+}
+
+func TestWHEN_TokenInsertedInRange_THEN_OnlyHalfOfRangeMoves(t *testing.T) {
+	h := &ConsistentHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNodeWithTokens("server1", []uint64{1000})
+	h.AddNodeWithTokens("server2", []uint64{2000})
+
+	before := cloneRing(h)
+
+	// server3 takes the first half of the hot range (1000, 2000] of server2
+	h.AddNodeWithTokens("server3", []uint64{1500})
+
+	moves, _ := Diff(before, h)
+	expected := []Move{{Start: 1001, End: 1500, From: "server2", To: "server3"}}
+
+	if !reflect.DeepEqual(moves, expected) {
+		t.Errorf("Expected moves %v, but got %v", expected, moves)
+	}
+}
+
+func TestWHEN_AddNodeWithInvalidTokens_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNodeWithTokens("server1", []uint64{1000})
+
+	invalid := map[string]struct {
+		node   string
+		tokens []uint64
+	}{
+		"empty node":     {"", []uint64{1}},
+		"no tokens":      {"server2", nil},
+		"duplicate":      {"server2", []uint64{1, 2, 1}},
+		"owned token":    {"server2", []uint64{1, 1000}},
+		"present node":   {"server1", []uint64{1}},
+		"larger than 32": {"server2", []uint64{math.MaxUint32 + 1}},
+	}
+
+	for name, c := range invalid {
+		if err := h.AddNodeWithTokens(c.node, c.tokens); err == nil {
+			t.Errorf("Expected non-nil error for %s but got nil", name)
+		}
+	}
+
+	if !reflect.DeepEqual(h.Tokens(), []uint64{1000}) {
+		t.Errorf("Expected the ring to be left unchanged, but got %v", h.Tokens())
+	}
+}
+
+func TestWHEN_RingChanges_THEN_ExplicitTokensNeverDerived(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h.AddNode("server1")
+	h.AddNodeWithTokens("server2", []uint64{42, 4242})
+
+	h.SetReplicas(20)
+
+	if tokens := h.NodeTokens("server2"); !reflect.DeepEqual(tokens, []uint64{42, 4242}) || len(h.NodeTokens("server1")) != 20 {
+		t.Errorf("Expected SetReplicas to leave the explicit tokens in place, but got %v", tokens)
+	}
+
+	if err := h.UpdateWeight("server2", 2); err == nil {
+		t.Error("Expected non-nil error as the tokens of the node are explicit but got nil")
+	}
+
+	h.RemoveNode("server2")
+
+	if len(h.Tokens()) != 20 || h.NodeTokens("server2") != nil {
+		t.Errorf("Expected the explicit tokens to be removed, but got %d tokens", len(h.Tokens()))
+	}
+}
+
+func TestWHEN_SnapshotWithExplicitTokens_THEN_ExplicitTokensRestored(t *testing.T) {
+	h := newSnapshotRing([]string{"server1", "server2"})
+	h.AddNodeWithTokens("server3", []uint64{7, 77, 777})
+
+	data, _ := json.Marshal(h.Snapshot())
+
+	var fromJSON Snapshot
+	json.Unmarshal(data, &fromJSON)
+
+	binary, _ := h.Snapshot().MarshalBinary()
+
+	var fromBinary Snapshot
+	if err := fromBinary.UnmarshalBinary(binary); err != nil {
+		t.Fatalf("Expected no errors to occur but got %s", err)
+	}
+
+	for _, s := range []*Snapshot{&fromJSON, &fromBinary} {
+		restored := &ConsistentHashing{Logger: h.Logger}
+		if err := restored.Restore(s); err != nil {
+			t.Fatalf("Expected no errors to occur but got %s", err)
+		}
+
+		assertSameRing(t, h, restored)
+
+		restored.SetReplicas(5)
+
+		if tokens := restored.NodeTokens("server3"); !reflect.DeepEqual(tokens, []uint64{7, 77, 777}) {
+			t.Errorf("Expected the restored tokens of `server3` to stay explicit, but got %v", tokens)
+		}
+	}
+
+	s := h.Snapshot()
+	s.Nodes[2].Tokens = []uint64{7, 8}
+
+	if err := h.Restore(s); err == nil {
+		t.Error("Expected non-nil error as token 8 is not owned by `server3` but got nil")
+	}
+}
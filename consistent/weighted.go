@@ -0,0 +1,239 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistent
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// With weighted nodes, the number of virtual nodes of a node in the ring is its
+// weight times Replicas, so that a node with a weight of 3 receives about three
+// times the keys of a node with a weight of 1. The tokens of a node are always
+// derived from its name and the index of the virtual node, hence changing the
+// weight only adds or removes the tokens of the last virtual nodes.
+
+// A member is a node of the ring. Members are shared between snapshots and are
+// copied before being changed, except for the load which is updated atomically
+type member struct {
+	weight   float64
+	replicas int
+	info     NodeInfo
+	load     *atomic.Int64
+	// tokens are the sorted explicit tokens of the node, nil when the tokens of the
+	// node are derived from its name
+	tokens []uint64
+}
+
+// AddWeightedNode will add a node or entity in the ring with a number of virtual
+// nodes proportional to its weight. The weight must be positive non 0, and give at
+// least one virtual node
+func (h *ConsistentHashing) AddWeightedNode(node string, weight float64) error {
+	h.logger().Info("AddWeightedNode", "node", node, "weight", weight)
+
+	if len(node) == 0 {
+		h.logger().Error("AddWeightedNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	if err := h.validateWeight(weight); err != nil {
+		return err
+	}
+
+	return h.update(func(r *ring) error {
+		if _, ok := r.members[node]; ok {
+			h.logger().Error("AddWeightedNode node already present", "node", node)
+			return errors.New("Expected node to not be present, use UpdateWeight instead")
+		}
+
+		if h.computeReplicas(weight) == 0 {
+			h.logger().Error("AddWeightedNode weight without virtual nodes", "node", node, "weight", weight)
+			return errors.New("Expected weight to give at least one virtual node")
+		}
+
+		h.addMember(r, node, weight)
+
+		return nil
+	})
+}
+
+// UpdateWeight changes the weight of a node already in the ring. Only the tokens
+// of the virtual nodes added or removed by the change of weight move, the other
+// tokens of the node are left in place
+func (h *ConsistentHashing) UpdateWeight(node string, weight float64) error {
+	h.logger().Info("UpdateWeight", "node", node, "weight", weight)
+
+	if err := h.validateWeight(weight); err != nil {
+		return err
+	}
+
+	return h.update(func(r *ring) error {
+		m, ok := r.members[node]
+		if !ok {
+			h.logger().Error("UpdateWeight unknown node", "node", node)
+			return errors.New("Expected node to be present")
+		}
+
+		if m.tokens != nil {
+			h.logger().Error("UpdateWeight node with explicit tokens", "node", node)
+			return errors.New("Expected node to have derived tokens, but its tokens are explicit")
+		}
+
+		replicas := h.computeReplicas(weight)
+		if replicas == 0 {
+			h.logger().Error("UpdateWeight weight without virtual nodes", "node", node, "weight", weight)
+			return errors.New("Expected weight to give at least one virtual node")
+		}
+
+		if replicas > m.replicas {
+			h.addTokens(r, node, m.replicas, replicas)
+		} else {
+			h.removeTokens(r, node, replicas, m.replicas)
+		}
+
+		updated := *m
+		updated.weight = weight
+		updated.replicas = replicas
+		r.members[node] = &updated
+
+		return nil
+	})
+}
+
+// Weight returns the weight of the given node, or 0 when the node is not in the ring
+func (h *ConsistentHashing) Weight(node string) float64 {
+	if m, ok := h.snapshot().members[node]; ok {
+		return m.weight
+	}
+	return 0
+}
+
+// Private function not exported to be able to add a node and its virtual nodes
+func (h *ConsistentHashing) addMember(r *ring, node string, weight float64) {
+	m := &member{
+		weight:   weight,
+		replicas: h.computeReplicas(weight),
+		load:     new(atomic.Int64),
+	}
+	r.members[node] = m
+
+	h.addTokens(r, node, 0, m.replicas)
+}
+
+// Private function not exported returning the number of virtual nodes for a weight
+func (h *ConsistentHashing) computeReplicas(weight float64) int {
+	return int(math.Round(weight * float64(h.Replicas)))
+}
+
+// Private function not exported checking the weight is positive non 0 and finite
+func (h *ConsistentHashing) validateWeight(weight float64) error {
+	if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		h.logger().Error("Invalid weight", "weight", weight)
+		return errors.New("Expected weight to be positive non 0")
+	}
+	return nil
+}
+
+// Private function not exported to be able to add the tokens of the virtual nodes
+// from (included) to (excluded) of the given node, keeping the tokens ordered
+func (h *ConsistentHashing) addTokens(r *ring, node string, from int, to int) {
+	added := make(map[uint64]bool, to-from)
+	tokens := make([]uint64, 0, to-from)
+
+	for i := from; i < to; i++ {
+		key := h.computeHash(r, node+strconv.Itoa(i))
+
+		if idx := r.search(key); idx < len(r.tokens) && r.tokens[idx] == key {
+			if owner := r.owners[idx]; owner != node {
+				h.logger().Warn("Token collision", "token", key, "node", node, "owner", owner)
+				r.collisions = append(r.collisions, Collision{Token: key, Node: node, Owner: owner})
+			}
+			continue
+		}
+
+		if !added[key] {
+			added[key] = true
+			tokens = append(tokens, key)
+		}
+	}
+
+	r.insert(tokens, node)
+}
+
+// Private function not exported to be able to remove the tokens of the virtual
+// nodes from (included) to (excluded) of the given node, keeping the tokens ordered.
+// The tokens the node won over colliding nodes are handed back to them
+func (h *ConsistentHashing) removeTokens(r *ring, node string, from int, to int) {
+	removed := make(map[uint64]bool, to-from)
+
+	for i := from; i < to; i++ {
+		removed[h.computeHash(r, node+strconv.Itoa(i))] = true
+	}
+
+	// a token also derived from a virtual node which is kept stays in the ring
+	for i := 0; i < from; i++ {
+		delete(removed, h.computeHash(r, node+strconv.Itoa(i)))
+	}
+
+	if len(removed) == 0 {
+		return
+	}
+
+	h.removeVnodes(r, func(token uint64, owner string) bool {
+		return owner == node && removed[token]
+	})
+}
+
+// Private function not exported merging the given tokens, owned by node, into new
+// sorted slices of tokens and owners
+func (r *ring) insert(tokens []uint64, node string) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i] < tokens[j]
+	})
+
+	merged := make([]uint64, 0, len(r.tokens)+len(tokens))
+	owners := make([]string, 0, len(r.tokens)+len(tokens))
+
+	i, j := 0, 0
+	for i < len(r.tokens) || j < len(tokens) {
+		if j == len(tokens) || (i < len(r.tokens) && r.tokens[i] < tokens[j]) {
+			merged = append(merged, r.tokens[i])
+			owners = append(owners, r.owners[i])
+			i++
+		} else {
+			merged = append(merged, tokens[j])
+			owners = append(owners, node)
+			j++
+		}
+	}
+
+	r.tokens = merged
+	r.owners = owners
+}
@@ -0,0 +1,192 @@
+package consistent
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_AddWeightedNode_THEN_VirtualNodesProportionalToWeight(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 100,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("small-box")
+	if err := h.AddWeightedNode("big-box", 3.0); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	tokens := make(map[string]int)
+	for _, node := range owners(h) {
+		tokens[node]++
+	}
+
+	if tokens["small-box"] != 100 || tokens["big-box"] != 300 {
+		t.Errorf("Expected 100 and 300 virtual nodes, but got %v", tokens)
+	}
+
+	if h.Weight("big-box") != 3.0 || h.Weight("small-box") != 1.0 || h.Weight("unknown") != 0 {
+		t.Errorf("Expected weights 3 and 1, but got %f and %f", h.Weight("big-box"), h.Weight("small-box"))
+	}
+}
+
+func TestWHEN_AddWeightedNodeWithInvalidWeight_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 10,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, weight := range []float64{-1, 0} {
+		if err := h.AddWeightedNode("server1", weight); err == nil {
+			t.Errorf("Expected non-nil error for weight %f but got nil", weight)
+		}
+	}
+
+	h.AddNode("server1")
+
+	if err := h.AddWeightedNode("server1", 2); err == nil {
+		t.Error("Expected non-nil error as the node is already present but got nil")
+	}
+
+	if err := h.UpdateWeight("unknown", 2); err == nil {
+		t.Error("Expected non-nil error as the node is unknown but got nil")
+	}
+}
+
+func TestWHEN_WeightWithoutVirtualNodes_THEN_ReturnError(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 100,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	if err := h.AddWeightedNode("server1", 0.001); err == nil {
+		t.Error("Expected non-nil error as the weight gives no virtual node but got nil")
+	}
+
+	if _, ok := h.snapshot().members["server1"]; ok {
+		t.Error("Expected the node to not be added")
+	}
+
+	h.AddNode("server1")
+
+	if err := h.UpdateWeight("server1", 0.001); err == nil {
+		t.Error("Expected non-nil error as the weight gives no virtual node but got nil")
+	}
+
+	if h.Weight("server1") != 1 || len(owners(h)) != 100 {
+		t.Errorf("Expected the weight and the tokens to be left unchanged, but got %f and %d tokens", h.Weight("server1"), len(owners(h)))
+	}
+}
+
+func TestWHEN_WeightedNodes_THEN_KeysDistributedByWeight(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 200,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddWeightedNode("small-box", 1)
+	h.AddWeightedNode("big-box", 3)
+
+	assigned := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		node, _ := h.Hash(fmt.Sprintf("key-%d", i), 0)
+		assigned[node]++
+	}
+
+	if assigned["big-box"] < 2*assigned["small-box"] {
+		t.Errorf("Expected `big-box` to receive about 3 times the keys of `small-box`, but got %v", assigned)
+	}
+}
+
+func TestWHEN_UpdateWeight_THEN_OnlyMinimalTokensMove(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 100,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddWeightedNode("server2", 2)
+
+	before := make(map[uint64]string)
+	for key, node := range owners(h) {
+		before[key] = node
+	}
+
+	h.UpdateWeight("server2", 2.5)
+
+	if len(owners(h)) != 350 || len(h.Tokens()) != 350 {
+		t.Errorf("Expected 350 tokens after the update, but got %d", len(owners(h)))
+	}
+
+	for key, node := range before {
+		if owners(h)[key] != node {
+			t.Errorf("Expected token %d to stay owned by `%s`, but got `%s`", key, node, owners(h)[key])
+		}
+	}
+
+	h.UpdateWeight("server2", 0.5)
+
+	if len(owners(h)) != 150 || len(h.Tokens()) != 150 {
+		t.Errorf("Expected 150 tokens after the update, but got %d", len(owners(h)))
+	}
+
+	for key, node := range owners(h) {
+		if before[key] != node {
+			t.Errorf("Expected token %d of `%s` to be one of the initial tokens", key, node)
+		}
+	}
+
+	h.RemoveNode("server2")
+
+	if len(owners(h)) != 100 || len(h.Tokens()) != 100 {
+		t.Errorf("Expected 100 tokens after the removal, but got %d", len(owners(h)))
+	}
+}
+
+// firstVnodeHashFunc places the first virtual node of `serverN` at token N, and every
+// other virtual node at token 42
+type firstVnodeHashFunc struct{}
+
+func (firstVnodeHashFunc) Name() string {
+	return "first-vnode"
+}
+
+func (firstVnodeHashFunc) Sum64(data []byte) uint64 {
+	if data[len(data)-1] == '0' {
+		return uint64(data[len(data)-2] - '0')
+	}
+	return 42
+}
+
+func TestWHEN_UpdateWeightRemovesCollidingTokens_THEN_CollisionsResolved(t *testing.T) {
+	h := &ConsistentHashing{
+		Replicas: 2,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: firstVnodeHashFunc{},
+	}
+
+	h.AddNode("server1")
+	h.AddWeightedNode("server2", 2)
+	h.AddNode("server3")
+
+	if owners(h)[42] != "server1" || len(h.Collisions()) != 4 {
+		t.Fatalf("Expected `server2` and `server3` to collide with `server1`, but got %v", h.Collisions())
+	}
+
+	// server2 drops its virtual nodes, which collided with server1
+	h.UpdateWeight("server2", 0.5)
+
+	expected := Collision{Token: 42, Node: "server3", Owner: "server1"}
+	if collisions := h.Collisions(); len(collisions) != 1 || collisions[0] != expected {
+		t.Errorf("Expected collision %v, but got %v", expected, collisions)
+	}
+
+	// server1 drops the token it won over server3
+	h.UpdateWeight("server1", 0.5)
+
+	if owners(h)[42] != "server3" || len(h.Collisions()) != 0 {
+		t.Errorf("Expected `server3` to claim token 42, but got `%s` and %v", owners(h)[42], h.Collisions())
+	}
+}
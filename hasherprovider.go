@@ -23,69 +23,160 @@
 package hasherprovider
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 
-	consistent "github.com/kounkou/hasherprovider/consistent"
-	random "github.com/kounkou/hasherprovider/random"
-	uniform "github.com/kounkou/hasherprovider/uniform"
+	hashfunc "github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
 )
 
 const (
-	CONSISTENT_HASHING = 0
-	RANDOM_HASHING     = 1
-	UNIFORM_HASHING    = 2
+	CONSISTENT_HASHING Algorithm = 0
+	RANDOM_HASHING     Algorithm = 1
+	UNIFORM_HASHING    Algorithm = 2
+	RENDEZVOUS_HASHING Algorithm = 3
+	JUMP_HASHING       Algorithm = 4
+	MAGLEV_HASHING     Algorithm = 5
+	P2C_HASHING        Algorithm = 6
 )
 
+// Hasher is implemented by every hashing algorithm. The other operations are
+// provided by the capability interfaces below, on which the hasher returned by
+// GetHasher can be type-asserted
 type Hasher interface {
 	Hash(uuid string, n int) (string, error)
-	AddNode(uuid string)
-	RemoveNode(uuid string)
-	SetReplicas(replicas int)
 }
 
-type HasherProvider struct {
-	Logger *log.Logger
+// MembershipHasher is implemented by the hashing algorithms managing a set of
+// named nodes, such as Consistent, Random, Uniform, Rendezvous, Maglev and P2C hashing
+type MembershipHasher interface {
+	Hasher
+	AddNode(uuid string) error
+	RemoveNode(uuid string) error
 }
 
-func (h *HasherProvider) GetHasher(hashFunction int) (Hasher, error) {
-	if h.Logger == nil {
-		h.Logger = log.New(os.Stdout, "hasherprovider ", log.LstdFlags)
-		h.Logger.Println("[WARN] Setting default logger")
-	}
+// ReplicatedHasher is implemented by the hashing algorithms placing several
+// replicas (virtual nodes) of every node, such as Consistent hashing
+type ReplicatedHasher interface {
+	Hasher
+	SetReplicas(replicas int) error
+}
 
-	h.Logger.Println("[INFO] Getting Hasher with hashing algorithm ", hashFunction)
+// ErrUnsupported is matched by errors.Is for every UnsupportedError
+var ErrUnsupported = errors.New("operation not supported")
 
-	hasherMap := h.initHasherMap()
-	hasher, ok := hasherMap[hashFunction]
+// UnsupportedError is returned when an operation is requested on a hasher which
+// does not implement it
+type UnsupportedError struct {
+	Hasher    string
+	Operation string
+}
 
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by %s", e.Operation, e.Hasher)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// AddNode adds the node to the hasher, or returns an UnsupportedError when the
+// hasher is not a MembershipHasher
+func AddNode(h Hasher, node string) error {
+	m, ok := h.(MembershipHasher)
 	if !ok {
-		h.Logger.Println("[ERROR] Getting the hasher failed for ", hashFunction)
-		return nil, fmt.Errorf("unknown hashing function type: %d", hashFunction)
+		return &UnsupportedError{Hasher: fmt.Sprintf("%T", h), Operation: "AddNode"}
 	}
+	return m.AddNode(node)
+}
 
-	h.Logger.Println("[INFO] Getting Hasher with hashing algorithm ", hashFunction, " succeeded")
+// RemoveNode removes the node from the hasher, or returns an UnsupportedError when
+// the hasher is not a MembershipHasher
+func RemoveNode(h Hasher, node string) error {
+	m, ok := h.(MembershipHasher)
+	if !ok {
+		return &UnsupportedError{Hasher: fmt.Sprintf("%T", h), Operation: "RemoveNode"}
+	}
+	return m.RemoveNode(node)
+}
 
-	return hasher, nil
+// SetReplicas sets the replicas of the hasher, or returns an UnsupportedError when
+// the hasher is not a ReplicatedHasher
+func SetReplicas(h Hasher, replicas int) error {
+	r, ok := h.(ReplicatedHasher)
+	if !ok {
+		return &UnsupportedError{Hasher: fmt.Sprintf("%T", h), Operation: "SetReplicas"}
+	}
+	return r.SetReplicas(replicas)
 }
 
-func (h *HasherProvider) initHasherMap() map[int]Hasher {
-	h.Logger.Println("[INFO] InitHasherMap")
+type HasherProvider struct {
+	// Logger is given to the hashing algorithms, with the name of the algorithm. When
+	// nil, nothing is logged
+	Logger *slog.Logger
+	// HashFunc is the hash function given to the hashing algorithms. When nil, every
+	// algorithm uses its own default hash function
+	HashFunc hashfunc.HashFunc
+}
 
-	var hasherMap map[int]Hasher
+// GetHasher returns a new hasher of the given algorithm, configured by the options.
+// The options are validated before the hasher is created, and an UnsupportedError is
+// returned when the algorithm does not support one of them
+func (h *HasherProvider) GetHasher(hashFunction Algorithm, opts ...Option) (Hasher, error) {
+	logger := h.logger().With("algorithm", hashFunction.String())
+
+	options := Options{Logger: h.Logger, HashFunc: h.HashFunc}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			logger.Error("Invalid option", "error", err)
+			return nil, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+
+	a, ok := lookupAlgorithm(hashFunction)
+	if !ok {
+		logger.Error("Unknown hashing algorithm")
+		return nil, fmt.Errorf("unknown hashing function type: %d", int(hashFunction))
+	}
+
+	options.Logger = logging.OrDiscard(options.Logger).With("algorithm", a.name)
+
+	hasher, err := a.factory(options)
+	if err != nil {
+		logger.Error("Creating the hasher failed", "error", err)
+		return nil, fmt.Errorf("creating %s hasher: %w", a.name, err)
+	}
+
+	if hasher == nil {
+		logger.Error("Creating the hasher failed, the factory returned no hasher")
+		return nil, fmt.Errorf("creating %s hasher: the factory returned no hasher", a.name)
+	}
+
+	if err := options.apply(hasher, a); err != nil {
+		logger.Error("Configuring the hasher failed", "error", err)
+		return nil, fmt.Errorf("configuring %s hasher: %w", a.name, err)
+	}
+
+	logger.Debug("Hasher created")
 
-	hasherMap = map[int]Hasher{
-		CONSISTENT_HASHING: &consistent.ConsistentHashing{
-			Nodes:    make(map[uint32]string),
-			Replicas: 0,
-			Logger:   h.Logger,
-		},
-		RANDOM_HASHING:  &random.RandomHashing{},
-		UNIFORM_HASHING: &uniform.UniformHashing{},
+	return hasher, nil
+}
+
+// GetHasherByName returns a new hasher of the algorithm registered under the given
+// name, as parsed by ParseAlgorithm, configured by the options
+func (h *HasherProvider) GetHasherByName(name string, opts ...Option) (Hasher, error) {
+	algo, err := ParseAlgorithm(name)
+	if err != nil {
+		h.logger().Error("Unknown hashing algorithm", "algorithm", name)
+		return nil, err
 	}
 
-	h.Logger.Println("[INFO] InitHasherMap successfully")
+	return h.GetHasher(algo, opts...)
+}
 
-	return hasherMap
+// Private function not exported returning the logger of the provider, which drops
+// every record when no logger was set
+func (h *HasherProvider) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
 }
@@ -1,17 +1,20 @@
 package hasherprovider
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"os"
 	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
 )
 
 func TestWHEN_requestedAlgoDoesNotExist_THEN_returnError(t *testing.T) {
 	hp := HasherProvider{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	algo := 3
+	algo := Algorithm(-1)
 	_, err := hp.GetHasher(algo)
 	if err == nil {
 		t.Errorf("Expected error for invalid algorithm type %d, but got nil", algo)
@@ -20,7 +23,7 @@ func TestWHEN_requestedAlgoDoesNotExist_THEN_returnError(t *testing.T) {
 
 func TestWHEN_requestForConsistentHasher_THEN_NoError(t *testing.T) {
 	hp := HasherProvider{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	algo := CONSISTENT_HASHING
@@ -32,7 +35,7 @@ func TestWHEN_requestForConsistentHasher_THEN_NoError(t *testing.T) {
 
 func TestWHEN_requestForRandomHasher_THEN_noError(t *testing.T) {
 	hp := HasherProvider{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	algo := RANDOM_HASHING
@@ -44,7 +47,7 @@ func TestWHEN_requestForRandomHasher_THEN_noError(t *testing.T) {
 
 func TestWHEN_requestForUniformHasher_THEN_NoError(t *testing.T) {
 	hp := HasherProvider{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	algo := UNIFORM_HASHING
@@ -54,9 +57,63 @@ func TestWHEN_requestForUniformHasher_THEN_NoError(t *testing.T) {
 	}
 }
 
+func TestWHEN_requestForRendezvousHasher_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	algo := RENDEZVOUS_HASHING
+	hasher, err := hp.GetHasher(algo)
+	if hasher == nil || err != nil {
+		t.Errorf("Unexpected error for valid algorithm type %d: %v", algo, err)
+	}
+}
+
+func TestWHEN_requestForJumpHasher_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	algo := JUMP_HASHING
+	hasher, err := hp.GetHasher(algo)
+	if hasher == nil || err != nil {
+		t.Errorf("Unexpected error for valid algorithm type %d: %v", algo, err)
+	}
+}
+
+func TestWHEN_requestForMaglevHasher_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	algo := MAGLEV_HASHING
+	hasher, err := hp.GetHasher(algo)
+	if hasher == nil || err != nil {
+		t.Errorf("Unexpected error for valid algorithm type %d: %v", algo, err)
+	}
+}
+
+func TestWHEN_HashFuncSet_THEN_HashFuncUsedByHasher(t *testing.T) {
+	hp := HasherProvider{
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: hashfunc.CRC32,
+	}
+
+	hasher, err := hp.GetHasher(UNIFORM_HASHING)
+	if err != nil {
+		t.Errorf("Unexpected error for valid algorithm type %d : %v", UNIFORM_HASHING, err)
+	}
+
+	// CRC32("123456789") = 0xe3069283, FNV-1a 64 bits would assign shard 7
+	result, _ := hasher.Hash("123456789", 10)
+	if result != "3" {
+		t.Errorf("Expected the CRC32 hash function to assign shard 3, but got %s", result)
+	}
+}
+
 func TestWHEN_fullFlow_THEN_Success(t *testing.T) {
 	hp := HasherProvider{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	algo := CONSISTENT_HASHING
@@ -66,10 +123,15 @@ func TestWHEN_fullFlow_THEN_Success(t *testing.T) {
 		t.Errorf("Unexpected error for valid algorithm type %d : %v", algo, err)
 	}
 
-	hasher.AddNode("1")
-	hasher.AddNode("5")
-	hasher.AddNode("8")
-	hasher.RemoveNode("8")
+	membership, ok := hasher.(MembershipHasher)
+	if !ok {
+		t.Fatalf("Expected hasher for algorithm type %d to be a MembershipHasher", algo)
+	}
+
+	membership.AddNode("1")
+	membership.AddNode("5")
+	membership.AddNode("8")
+	membership.RemoveNode("8")
 
 	_, err = hasher.Hash("test", 4)
 
@@ -95,12 +157,108 @@ func TestWHEN_logIsNotSet_THEN_NoPanic(t *testing.T) {
 
 	_, err := hasher.Hash("test", 4)
 
-	hasher.AddNode("1")
-	hasher.AddNode("5")
-	hasher.AddNode("8")
-	hasher.RemoveNode("8")
+	AddNode(hasher, "1")
+	AddNode(hasher, "5")
+	AddNode(hasher, "8")
+	RemoveNode(hasher, "8")
 
 	if err != nil {
 		t.Errorf("Unexpected error for valid Hashing %d : %v", algo, err)
 	}
 }
+
+func TestWHEN_requestForP2CHasher_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	algo := P2C_HASHING
+	hasher, err := hp.GetHasher(algo)
+	if hasher == nil || err != nil {
+		t.Errorf("Unexpected error for valid algorithm type %d: %v", algo, err)
+	}
+}
+
+func TestWHEN_requestForHasher_THEN_CapabilitiesMatchAlgorithm(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	capabilities := []struct {
+		algo       Algorithm
+		membership bool
+		replicated bool
+	}{
+		{CONSISTENT_HASHING, true, true},
+		{RANDOM_HASHING, true, false},
+		{UNIFORM_HASHING, true, false},
+		{RENDEZVOUS_HASHING, true, false},
+		{JUMP_HASHING, false, false},
+		{MAGLEV_HASHING, true, false},
+		{P2C_HASHING, true, false},
+	}
+
+	for _, c := range capabilities {
+		hasher, _ := hp.GetHasher(c.algo)
+
+		if _, ok := hasher.(MembershipHasher); ok != c.membership {
+			t.Errorf("Expected MembershipHasher to be %t for algorithm type %d", c.membership, c.algo)
+		}
+
+		if _, ok := hasher.(ReplicatedHasher); ok != c.replicated {
+			t.Errorf("Expected ReplicatedHasher to be %t for algorithm type %d", c.replicated, c.algo)
+		}
+	}
+}
+
+func TestWHEN_unsupportedOperation_THEN_ReturnUnsupportedError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Function panicked with %v", r)
+		}
+	}()
+
+	for _, algo := range []Algorithm{JUMP_HASHING} {
+		hasher, _ := hp.GetHasher(algo)
+
+		for _, err := range []error{AddNode(hasher, "node"), RemoveNode(hasher, "node"), SetReplicas(hasher, 4)} {
+			var unsupported *UnsupportedError
+			if !errors.Is(err, ErrUnsupported) || !errors.As(err, &unsupported) {
+				t.Errorf("Expected an UnsupportedError for algorithm type %d, but got %v", algo, err)
+			}
+		}
+	}
+
+	for _, algo := range []Algorithm{RANDOM_HASHING, UNIFORM_HASHING, MAGLEV_HASHING} {
+		hasher, _ := hp.GetHasher(algo)
+
+		if err := SetReplicas(hasher, 4); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expected SetReplicas to be unsupported for algorithm type %d, but got %v", algo, err)
+		}
+
+		if err := AddNode(hasher, "node"); err != nil {
+			t.Errorf("Expected AddNode to be supported for algorithm type %d, but got %v", algo, err)
+		}
+	}
+}
+
+func TestWHEN_noLoggerSet_THEN_HashersUsableWithoutLogging(t *testing.T) {
+	hp := HasherProvider{}
+
+	for _, name := range List()[:P2C_HASHING+1] {
+		hasher, err := hp.GetHasherByName(name)
+		if err != nil {
+			t.Fatalf("Unexpected error for valid algorithm %s: %v", name, err)
+		}
+
+		AddNode(hasher, "server1")
+
+		if _, err := hasher.Hash("key", 1); err != nil {
+			t.Errorf("Unexpected error hashing with algorithm %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashfunc
+
+import (
+	"hash/crc32"
+	"hash/fnv"
+)
+
+// A HashFunc computes the hash of the keys and nodes given to the hashing
+// algorithms. Hash functions returning 32 bits values return them in the low
+// bits of the 64 bits value.
+type HashFunc interface {
+	// Name returns the name of the hash function, such as "fnv1a64"
+	Name() string
+	// Sum64 returns the hash of the given data
+	Sum64(data []byte) uint64
+}
+
+var (
+	// FNV1a32 is the 32 bits FNV-1a hash function
+	FNV1a32 HashFunc = fnv1a32{}
+	// FNV1a64 is the 64 bits FNV-1a hash function
+	FNV1a64 HashFunc = fnv1a64{}
+	// CRC32 is the 32 bits CRC using the Castagnoli polynomial
+	CRC32 HashFunc = crc32c{}
+	// Murmur3 is the 64 bits MurmurHash3 (x64 128 bits variant) hash function with a seed of 0
+	Murmur3 HashFunc = NewMurmur3(0)
+	// XXHash64 is the 64 bits xxHash hash function with a seed of 0
+	XXHash64 HashFunc = NewXXHash64(0)
+)
+
+type fnv1a32 struct{}
+
+func (fnv1a32) Name() string {
+	return "fnv1a32"
+}
+
+func (fnv1a32) Sum64(data []byte) uint64 {
+	hash := fnv.New32a()
+	hash.Write(data)
+	return uint64(hash.Sum32())
+}
+
+type fnv1a64 struct{}
+
+func (fnv1a64) Name() string {
+	return "fnv1a64"
+}
+
+func (fnv1a64) Sum64(data []byte) uint64 {
+	hash := fnv.New64a()
+	hash.Write(data)
+	return hash.Sum64()
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type crc32c struct{}
+
+func (crc32c) Name() string {
+	return "crc32c"
+}
+
+func (crc32c) Sum64(data []byte) uint64 {
+	return uint64(crc32.Checksum(data, castagnoli))
+}
+
+// Mix64 finalizes a 64 bits hash with the finalizer of MurmurHash3 (fmix64), so that
+// every bit of the input changes about half of the bits of the result. The
+// algorithms mix the hash of their keys, which spreads close keys and the 32 bits
+// hash functions over the whole 64 bits range
+func Mix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
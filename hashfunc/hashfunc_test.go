@@ -0,0 +1,78 @@
+package hashfunc
+
+import (
+	"testing"
+)
+
+type Vector struct {
+	input    string
+	expected uint64
+}
+
+func TestWHEN_FNV1a32_THEN_MatchPublishedVectors(t *testing.T) {
+	vectors := []Vector{
+		{"", 0x811c9dc5},
+		{"a", 0xe40c292c},
+		{"foobar", 0xbf9cf968},
+	}
+
+	for _, v := range vectors {
+		if actual := FNV1a32.Sum64([]byte(v.input)); actual != v.expected {
+			t.Errorf("FNV1a32(%q) = %#x; expected %#x", v.input, actual, v.expected)
+		}
+	}
+}
+
+func TestWHEN_FNV1a64_THEN_MatchPublishedVectors(t *testing.T) {
+	vectors := []Vector{
+		{"", 0xcbf29ce484222325},
+		{"a", 0xaf63dc4c8601ec8c},
+		{"foobar", 0x85944171f73967e8},
+	}
+
+	for _, v := range vectors {
+		if actual := FNV1a64.Sum64([]byte(v.input)); actual != v.expected {
+			t.Errorf("FNV1a64(%q) = %#x; expected %#x", v.input, actual, v.expected)
+		}
+	}
+}
+
+func TestWHEN_CRC32_THEN_MatchPublishedVectors(t *testing.T) {
+	vectors := []Vector{
+		{"", 0},
+		{"123456789", 0xe3069283},
+	}
+
+	for _, v := range vectors {
+		if actual := CRC32.Sum64([]byte(v.input)); actual != v.expected {
+			t.Errorf("CRC32(%q) = %#x; expected %#x", v.input, actual, v.expected)
+		}
+	}
+}
+
+func TestWHEN_BuiltInHashFunc_THEN_NamesAreDistinct(t *testing.T) {
+	names := make(map[string]bool)
+
+	for _, h := range []HashFunc{FNV1a32, FNV1a64, CRC32, Murmur3, XXHash64, NewSipHash([16]byte{})} {
+		if names[h.Name()] {
+			t.Errorf("Expected hash function names to be distinct, but got `%s` twice", h.Name())
+		}
+		names[h.Name()] = true
+	}
+}
+
+func TestWHEN_Mix64_THEN_MatchMurmur3Finalizer(t *testing.T) {
+	vectors := []struct {
+		input    uint64
+		expected uint64
+	}{
+		{0, 0},
+		{1, 12994781566227106604},
+	}
+
+	for _, v := range vectors {
+		if result := Mix64(v.input); result != v.expected {
+			t.Errorf("Expected Mix64(%d) to be %d, but got %d", v.input, v.expected, result)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashfunc
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	murmur3C1 = 0x87c37b91114253d5
+	murmur3C2 = 0x4cf5ad432745937f
+)
+
+type murmur3 struct {
+	seed uint32
+}
+
+// NewMurmur3 returns the 64 bits MurmurHash3 hash function with the given seed.
+// The x64 128 bits variant of MurmurHash3 is computed and its first 64 bits are returned
+func NewMurmur3(seed uint32) HashFunc {
+	return murmur3{seed: seed}
+}
+
+func (h murmur3) Name() string {
+	return "murmur3"
+}
+
+func (h murmur3) Sum64(data []byte) uint64 {
+	h1, _ := h.sum128(data)
+	return h1
+}
+
+// Private function not exported computing both halves of MurmurHash3_x64_128
+func (h murmur3) sum128(data []byte) (uint64, uint64) {
+	h1, h2 := uint64(h.seed), uint64(h.seed)
+	length := len(data)
+
+	for ; len(data) >= 16; data = data[16:] {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+
+		h1 ^= murmur3MixK1(k1)
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		h2 ^= murmur3MixK2(k2)
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		if i >= 8 {
+			k2 = k2<<8 | uint64(data[i])
+		} else {
+			k1 = k1<<8 | uint64(data[i])
+		}
+	}
+	if len(data) > 8 {
+		h2 ^= murmur3MixK2(k2)
+	}
+	if len(data) > 0 {
+		h1 ^= murmur3MixK1(k1)
+	}
+
+	h1 ^= uint64(length)
+	h2 ^= uint64(length)
+
+	h1 += h2
+	h2 += h1
+
+	h1 = Mix64(h1)
+	h2 = Mix64(h2)
+
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
+
+func murmur3MixK1(k1 uint64) uint64 {
+	k1 *= murmur3C1
+	k1 = bits.RotateLeft64(k1, 31)
+	return k1 * murmur3C2
+}
+
+func murmur3MixK2(k2 uint64) uint64 {
+	k2 *= murmur3C2
+	k2 = bits.RotateLeft64(k2, 33)
+	return k2 * murmur3C1
+}
@@ -0,0 +1,39 @@
+package hashfunc
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestWHEN_Murmur3_THEN_MatchPublishedVectors(t *testing.T) {
+	vectors := []Vector{
+		{"", 0},
+		{"The quick brown fox jumps over the lazy dog", 0xe34bbc7bbc071b6c},
+	}
+
+	for _, v := range vectors {
+		if actual := Murmur3.Sum64([]byte(v.input)); actual != v.expected {
+			t.Errorf("Murmur3(%q) = %#x; expected %#x", v.input, actual, v.expected)
+		}
+	}
+}
+
+// The verification value of SMHasher hashes the keys {}, {0}, {0, 1}, ... {0, ..., 254}
+// with the seeds 256 to 1, then hashes the concatenation of the 256 results
+func TestWHEN_Murmur3_THEN_MatchSMHasherVerificationValue(t *testing.T) {
+	key := make([]byte, 256)
+	hashes := make([]byte, 16*256)
+
+	for i := 0; i < 256; i++ {
+		key[i] = byte(i)
+		h1, h2 := murmur3{seed: uint32(256 - i)}.sum128(key[:i])
+		binary.LittleEndian.PutUint64(hashes[16*i:], h1)
+		binary.LittleEndian.PutUint64(hashes[16*i+8:], h2)
+	}
+
+	h1, _ := murmur3{seed: 0}.sum128(hashes)
+
+	if actual := uint32(h1); actual != 0x6384ba69 {
+		t.Errorf("Murmur3 verification value = %#x; expected %#x", actual, 0x6384ba69)
+	}
+}
@@ -0,0 +1,98 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashfunc
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+type siphash struct {
+	k0 uint64
+	k1 uint64
+}
+
+// NewSipHash returns the keyed SipHash-2-4 hash function using the given 128 bits
+// key. A secret key prevents clients from crafting keys which collide on purpose
+func NewSipHash(key [16]byte) HashFunc {
+	return siphash{
+		k0: binary.LittleEndian.Uint64(key[:8]),
+		k1: binary.LittleEndian.Uint64(key[8:]),
+	}
+}
+
+func (h siphash) Name() string {
+	return "siphash"
+}
+
+func (h siphash) Sum64(data []byte) uint64 {
+	v0 := h.k0 ^ 0x736f6d6570736575
+	v1 := h.k1 ^ 0x646f72616e646f6d
+	v2 := h.k0 ^ 0x6c7967656e657261
+	v3 := h.k1 ^ 0x7465646279746573
+
+	length := len(data)
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	for ; len(data) >= 8; data = data[8:] {
+		m := binary.LittleEndian.Uint64(data)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	m := uint64(length) << 56
+	for i := len(data) - 1; i >= 0; i-- {
+		m |= uint64(data[i]) << (8 * uint(i))
+	}
+
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
@@ -0,0 +1,38 @@
+package hashfunc
+
+import (
+	"testing"
+)
+
+// Test vectors of the SipHash paper, using the key 00 01 02 ... 0f and the
+// messages {}, {00}, {00, 01}, ... {00, ..., 0e}
+func TestWHEN_SipHash_THEN_MatchPublishedVectors(t *testing.T) {
+	var key [16]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	message := make([]byte, 16)
+	for i := range message {
+		message[i] = byte(i)
+	}
+
+	vectors := map[int]uint64{
+		0:  0x726fdb47dd0e0e31,
+		1:  0x74f839c593dc67fd,
+		15: 0xa129ca6149be45e5,
+	}
+
+	h := NewSipHash(key)
+	for length, expected := range vectors {
+		if actual := h.Sum64(message[:length]); actual != expected {
+			t.Errorf("SipHash(%d bytes) = %#x; expected %#x", length, actual, expected)
+		}
+	}
+}
+
+func TestWHEN_SipHashWithDifferentKey_THEN_ResultDiffers(t *testing.T) {
+	if NewSipHash([16]byte{1}).Sum64([]byte("abc")) == NewSipHash([16]byte{2}).Sum64([]byte("abc")) {
+		t.Error("Expected the key to change the hash of the key")
+	}
+}
@@ -0,0 +1,113 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashfunc
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+type xxhash64 struct {
+	seed uint64
+}
+
+// NewXXHash64 returns the 64 bits xxHash (XXH64) hash function with the given seed
+func NewXXHash64(seed uint64) HashFunc {
+	return xxhash64{seed: seed}
+}
+
+func (h xxhash64) Name() string {
+	return "xxhash64"
+}
+
+func (h xxhash64) Sum64(data []byte) uint64 {
+	length := len(data)
+
+	var acc uint64
+	if length >= 32 {
+		v1 := h.seed + xxPrime1 + xxPrime2
+		v2 := h.seed + xxPrime2
+		v3 := h.seed
+		v4 := h.seed - xxPrime1
+
+		for ; len(data) >= 32; data = data[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(data))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(data[24:]))
+		}
+
+		acc = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		acc = xxMergeRound(acc, v1)
+		acc = xxMergeRound(acc, v2)
+		acc = xxMergeRound(acc, v3)
+		acc = xxMergeRound(acc, v4)
+	} else {
+		acc = h.seed + xxPrime5
+	}
+
+	acc += uint64(length)
+
+	for ; len(data) >= 8; data = data[8:] {
+		acc ^= xxRound(0, binary.LittleEndian.Uint64(data))
+		acc = bits.RotateLeft64(acc, 27)*xxPrime1 + xxPrime4
+	}
+
+	if len(data) >= 4 {
+		acc ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime1
+		acc = bits.RotateLeft64(acc, 23)*xxPrime2 + xxPrime3
+		data = data[4:]
+	}
+
+	for _, b := range data {
+		acc ^= uint64(b) * xxPrime5
+		acc = bits.RotateLeft64(acc, 11) * xxPrime1
+	}
+
+	acc ^= acc >> 33
+	acc *= xxPrime2
+	acc ^= acc >> 29
+	acc *= xxPrime3
+	acc ^= acc >> 32
+
+	return acc
+}
+
+func xxRound(acc uint64, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc uint64, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
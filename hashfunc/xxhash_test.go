@@ -0,0 +1,26 @@
+package hashfunc
+
+import (
+	"testing"
+)
+
+func TestWHEN_XXHash64_THEN_MatchPublishedVectors(t *testing.T) {
+	vectors := []Vector{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+
+	for _, v := range vectors {
+		if actual := XXHash64.Sum64([]byte(v.input)); actual != v.expected {
+			t.Errorf("XXHash64(%q) = %#x; expected %#x", v.input, actual, v.expected)
+		}
+	}
+}
+
+func TestWHEN_XXHash64WithSeed_THEN_ResultDiffers(t *testing.T) {
+	if NewXXHash64(1).Sum64([]byte("abc")) == XXHash64.Sum64([]byte("abc")) {
+		t.Error("Expected the seed to change the hash of the key")
+	}
+}
@@ -0,0 +1,65 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// The hashers log through a *slog.Logger, at the debug level for every lookup and at
+// the info level for the changes of their nodes. When no logger was set, they log
+// through Discard.
+
+// Discard is a logger dropping every record, without formatting it
+var Discard = slog.New(discardHandler{})
+
+// OrDiscard returns the given logger, or Discard when the logger is nil
+func OrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return Discard
+	}
+	return logger
+}
+
+// KeyHash returns the key_hash attribute of the given key. The keys are never logged
+// as is, only their FNV-1a 64 bits hash, which is only computed when the record is
+// handled. The same key has the same key_hash whatever the hashing algorithm
+func KeyHash(key string) slog.Attr {
+	return slog.Any("key_hash", keyHash(key))
+}
+
+type keyHash string
+
+func (k keyHash) LogValue() slog.Value {
+	return slog.Uint64Value(hashfunc.FNV1a64.Sum64([]byte(k)))
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestWHEN_NoLogger_THEN_RecordsDropped(t *testing.T) {
+	logger := OrDiscard(nil)
+
+	if logger != Discard {
+		t.Error("Expected the discard logger when no logger is set")
+	}
+
+	if logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected the discard logger to be disabled for every level")
+	}
+
+	set := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if OrDiscard(set) != set {
+		t.Error("Expected the logger set to be returned")
+	}
+}
+
+func TestWHEN_KeyLogged_THEN_OnlyItsHashWritten(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, nil))
+
+	logger.Info("Hash", KeyHash("123456789"))
+
+	// FNV-1a 64 bits of "123456789"
+	if expected := "key_hash=492395637191921148"; !bytes.Contains(buffer.Bytes(), []byte(expected)) {
+		t.Errorf("Expected %s, but got %q", expected, buffer.String())
+	}
+
+	if bytes.Contains(buffer.Bytes(), []byte("123456789 ")) {
+		t.Errorf("Expected the key to not be logged, but got %q", buffer.String())
+	}
+}
@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jump
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+type JumpHashing struct {
+	Logger   *slog.Logger
+	HashFunc hashfunc.HashFunc
+}
+
+// Jump hashing (Lamping & Veach, "A Fast, Minimal Memory, Consistent Hash Algorithm")
+// is used to distribute the uuid's associated (example events...) across a set of
+// numbered shards. Unlike the Uniform hashing, when the number of shards grows from
+// n to n+1, only 1/(n+1) of the uuid's move, and they all move to the new shard.
+// Shards can only be added or removed at the end of the range
+func (h JumpHashing) Hash(uuid string, shards int) (string, error) {
+	if shards <= 0 || len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid), "shards", shards)
+		return "", errors.New("Expected shards to be positive non 0")
+	}
+
+	return strconv.Itoa(h.computeBucket(h.computeHash(uuid), shards)), nil
+}
+
+// Private function not exported to be able to compute the hash of the provided key,
+// using FNV-1a 64 bits by default
+func (h JumpHashing) computeHash(uuid string) uint64 {
+	if h.HashFunc == nil {
+		return hashfunc.FNV1a64.Sum64([]byte(uuid))
+	}
+	return h.HashFunc.Sum64([]byte(uuid))
+}
+
+// Private function not exported implementing the jump consistent hash. A linear
+// congruential generator seeded with the key decides, bucket after bucket, whether
+// the key jumps forward, until the jump goes past the number of buckets
+func (h JumpHashing) computeBucket(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return int(b)
+}
+
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h JumpHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
+}
@@ -0,0 +1,95 @@
+package jump
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+func TestWHEN_HashFunctionCalledWithNullEvent_THEN_NullPointerExceptionThrown(t *testing.T) {
+	hasher := &JumpHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	_, err := hasher.Hash("", 3)
+	if err == nil {
+		t.Error("Expected non-nil error as event is empty but got nil")
+	}
+}
+
+func TestWHEN_HashFunctionCalledWithNullShards_THEN_NullPointerExceptionThrown(t *testing.T) {
+	hasher := &JumpHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	_, err := hasher.Hash("1", 0)
+	if err == nil {
+		t.Error("Expected non-nil error as shards number is 0 but got nil")
+	}
+}
+
+func TestWHEN_HashFunctionCalledWithKeyAndShardNumbers_THEN_ResultInRange(t *testing.T) {
+	hasher := &JumpHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for shards := 1; shards < 50; shards++ {
+		for i := 0; i < 100; i++ {
+			result, err := hasher.Hash(fmt.Sprintf("key-%d", i), shards)
+			if err != nil {
+				t.Errorf("Expected no errors to occur but got %s", err)
+			}
+
+			shard, _ := strconv.Atoi(result)
+			if shard < 0 || shard >= shards {
+				t.Errorf("Hash(key-%d, %d) = %s; expected a shard in [0, %d)", i, shards, result, shards)
+			}
+		}
+	}
+}
+
+func TestWHEN_ShardsGrow_THEN_OnlyKeysMovingToNewShardMove(t *testing.T) {
+	hasher := &JumpHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	keys := 10000
+	shards := 10
+	moved := 0
+
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before, _ := hasher.Hash(key, shards)
+		after, _ := hasher.Hash(key, shards+1)
+
+		if before != after {
+			if after != strconv.Itoa(shards) {
+				t.Errorf("Expected key `%s` to stay on `%s` or move to `%d`, but got `%s`", key, before, shards, after)
+			}
+			moved++
+		}
+	}
+
+	// about 1/11th of the keys are expected to move
+	if moved < keys/15 || moved > keys/8 {
+		t.Errorf("Expected about %d keys to move, but %d keys moved", keys/(shards+1), moved)
+	}
+}
+
+func TestWHEN_HashFuncSet_THEN_BucketComputedFromHashFunc(t *testing.T) {
+	hasher := &JumpHashing{
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: hashfunc.Murmur3,
+	}
+
+	result, _ := hasher.Hash("hello", 100)
+	expected := strconv.Itoa(hasher.computeBucket(hashfunc.Murmur3.Sum64([]byte("hello")), 100))
+
+	if result != expected {
+		t.Errorf("Hash(hello, 100) = %s; expected %s", result, expected)
+	}
+}
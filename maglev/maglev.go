@@ -0,0 +1,301 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package maglev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+// DefaultTableSize is the size of the lookup table used when no table size was
+// set with SetTableSize. It must be a prime number, much larger than the number of nodes
+const DefaultTableSize = 65537
+
+// MaxTableSize is the largest size of the lookup table accepted by SetTableSize, the
+// table and the permutations of the nodes being built in memory on every rebuild
+const MaxTableSize = 5000011
+
+// With Maglev Hashing, every node fills the slots of a lookup table following its
+// own permutation of the table, in turn, until the table is full. Each node then
+// owns almost the same number of slots, and looking up the node of a key is a single
+// access to the table. Rebuilding the table after a change of nodes only moves a
+// small number of slots to a different node.
+//
+// MaglevHashing is safe for concurrent use. The lookup table is rebuilt under a lock
+// and published atomically, so that lookups never take a lock.
+
+type MaglevHashing struct {
+	Logger   *slog.Logger
+	HashFunc hashfunc.HashFunc
+	mu       sync.Mutex
+	table    atomic.Pointer[table]
+}
+
+// A table is an immutable lookup table. The owner of slot i is members[lookup[i]],
+// and the members are sorted
+type table struct {
+	members []string
+	lookup  []int
+	size    int
+	changed int
+}
+
+// SetTableSize set the number of slots of the lookup table and rebuilds it. The size
+// must be a prime number so that every permutation visits all the slots, and must not
+// be greater than MaxTableSize
+func (h *MaglevHashing) SetTableSize(size int) error {
+	if size > MaxTableSize {
+		h.logger().Error("SetTableSize failed", "table_size", size, "max_table_size", MaxTableSize)
+		return fmt.Errorf("Expected table size to be lower or equal to %d", MaxTableSize)
+	}
+
+	if size <= 1 || !isPrime(size) {
+		h.logger().Error("SetTableSize failed", "table_size", size)
+		return errors.New("Expected table size to be a prime number")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.rebuild(h.snapshot().members, size)
+
+	return nil
+}
+
+// TableSize returns the number of slots of the lookup table
+func (h *MaglevHashing) TableSize() int {
+	return h.snapshot().size
+}
+
+// ChangedSlots returns the number of slots of the lookup table which changed
+// owner during the last rebuild, triggered by AddNode, RemoveNode or SetTableSize
+func (h *MaglevHashing) ChangedSlots() int {
+	return h.snapshot().changed
+}
+
+// Nodes returns the sorted nodes of the lookup table
+func (h *MaglevHashing) Nodes() []string {
+	return append([]string(nil), h.snapshot().members...)
+}
+
+// AddNode will add a node or entity to the nodes and rebuild the lookup table.
+// Adding a node which is already present has no effect
+func (h *MaglevHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
+
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	for _, n := range current.members {
+		if n == node {
+			h.logger().Warn("AddNode node already present", "node", node)
+			return nil
+		}
+	}
+
+	members := make([]string, 0, len(current.members)+1)
+	members = append(members, current.members...)
+	members = append(members, node)
+
+	h.rebuild(members, current.size)
+
+	return nil
+}
+
+// RemoveNode will remove a node or entity from the nodes and rebuild the lookup
+// table. The slots of the removed node are reassigned to the remaining nodes.
+// Removing a node which is not present has no effect
+func (h *MaglevHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	for i, n := range current.members {
+		if n == node {
+			members := make([]string, 0, len(current.members)-1)
+			members = append(members, current.members[:i]...)
+			members = append(members, current.members[i+1:]...)
+
+			h.rebuild(members, current.size)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Private function not exported returning the current lookup table, which must
+// NOT be changed. Before the first rebuild, the table is empty
+func (h *MaglevHashing) snapshot() *table {
+	if t := h.table.Load(); t != nil {
+		return t
+	}
+	return &table{size: DefaultTableSize}
+}
+
+// Private function not exported to be able to populate the lookup table from the
+// permutations of the nodes, then publish it. The nodes are sorted first so that
+// the table does not depend on the order in which the nodes were added.
+// It must be called with the lock held
+func (h *MaglevHashing) rebuild(nodes []string, size int) {
+	members := append([]string(nil), nodes...)
+	sort.Strings(members)
+
+	lookup := make([]int, size)
+	for i := range lookup {
+		lookup[i] = -1
+	}
+
+	if len(members) > 0 {
+		positions := make([]uint64, len(members))
+		skips := make([]uint64, len(members))
+
+		for i, node := range members {
+			offset, skip := h.computePermutation(node, uint64(size))
+			positions[i] = offset
+			skips[i] = skip
+		}
+
+		filled := 0
+		for filled < size {
+			for i := range members {
+				for lookup[positions[i]] >= 0 {
+					positions[i] = (positions[i] + skips[i]) % uint64(size)
+				}
+
+				lookup[positions[i]] = i
+				positions[i] = (positions[i] + skips[i]) % uint64(size)
+				filled++
+
+				if filled == size {
+					break
+				}
+			}
+		}
+	}
+
+	next := &table{members: members, lookup: lookup, size: size}
+	current := h.snapshot()
+
+	for i := range lookup {
+		if next.ownerOf(i) != current.ownerOf(i) {
+			next.changed++
+		}
+	}
+
+	h.table.Store(next)
+
+	h.logger().Info("Lookup table rebuilt", "table_size", size, "changed", next.changed)
+}
+
+// Private function not exported returning the node owning the given slot, or an
+// empty string when the slot does not exist or has no owner
+func (t *table) ownerOf(slot int) string {
+	if slot >= len(t.lookup) || t.lookup[slot] < 0 {
+		return ""
+	}
+	return t.members[t.lookup[slot]]
+}
+
+// Private function not exported to be able to compute the offset and the skip of
+// the permutation of the given node, using two different hash functions
+func (h *MaglevHashing) computePermutation(node string, size uint64) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write([]byte(node))
+
+	h2 := fnv.New64()
+	h2.Write([]byte(node))
+
+	return h1.Sum64() % size, h2.Sum64()%(size-1) + 1
+}
+
+// Private function not exported to be able to compute the hash of the provided key,
+// using FNV-1a 64 bits by default. The permutations of the nodes do not depend on
+// the hash function, so that the lookup table only depends on the nodes
+func (h *MaglevHashing) computeHash(uuid string) uint64 {
+	if h.HashFunc == nil {
+		return hashfunc.FNV1a64.Sum64([]byte(uuid))
+	}
+	return h.HashFunc.Sum64([]byte(uuid))
+}
+
+// Hash hashes the given input and reads its owner in the lookup table.
+// It returns the node to which the uuid will be assigned
+func (h *MaglevHashing) Hash(uuid string, _ int) (string, error) {
+	if len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid))
+		return "", errors.New("Expected uuid to be non-empty")
+	}
+
+	t := h.snapshot()
+
+	if len(t.members) == 0 {
+		return "", nil
+	}
+
+	node := t.members[t.lookup[h.computeHash(uuid)%uint64(len(t.lookup))]]
+
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("Hash", logging.KeyHash(uuid), "node", node)
+	}
+
+	return node, nil
+}
+
+// Private function not exported checking whether n is a prime number
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *MaglevHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
+}
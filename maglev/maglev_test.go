@@ -0,0 +1,166 @@
+package maglev
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_providedWithEmptyUUID_THEN_ReturnError(t *testing.T) {
+	h := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("node1")
+
+	result, err := h.Hash("", 0)
+
+	if err == nil || len(result) != 0 {
+		t.Errorf("Expected an error and an empty node, but got `%s`", result)
+	}
+}
+
+func TestWHEN_noNodeAdded_THEN_ReturnEmptyString(t *testing.T) {
+	h := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	result, err := h.Hash("test", 0)
+
+	if err != nil || len(result) != 0 {
+		t.Errorf("Expected no error and an empty node, but got `%s` and %v", result, err)
+	}
+}
+
+func TestWHEN_SetTableSizeWithNonPrime_THEN_ReturnError(t *testing.T) {
+	h := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, size := range []int{-7, 0, 1, 4, 65536} {
+		if err := h.SetTableSize(size); err == nil {
+			t.Errorf("Expected non-nil error for table size %d but got nil", size)
+		}
+	}
+
+	if h.TableSize() != DefaultTableSize {
+		t.Errorf("Expected the table size to stay %d, but got %d", DefaultTableSize, h.TableSize())
+	}
+
+	// primes above the maximum table size
+	for _, size := range []int{5000077, 2147483647} {
+		if err := h.SetTableSize(size); err == nil {
+			t.Errorf("Expected non-nil error for table size %d but got nil", size)
+		}
+	}
+
+	if h.TableSize() != DefaultTableSize {
+		t.Errorf("Expected the table size to stay %d, but got %d", DefaultTableSize, h.TableSize())
+	}
+
+	if err := h.SetTableSize(251); err != nil || h.TableSize() != 251 {
+		t.Errorf("Expected table size 251 to be accepted, but got %d and %v", h.TableSize(), err)
+	}
+}
+
+func TestWHEN_NodesAdded_THEN_SlotsEvenlyDistributed(t *testing.T) {
+	h := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for i := 0; i < 10; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	slots := make(map[string]int)
+	for i := range h.snapshot().lookup {
+		slots[h.snapshot().ownerOf(i)]++
+	}
+
+	expected := DefaultTableSize / 10
+	for node, count := range slots {
+		if count < expected-expected/100 || count > expected+expected/100 {
+			t.Errorf("Expected node `%s` to own about %d slots, but got %d", node, expected, count)
+		}
+	}
+}
+
+func TestWHEN_NodesAddedInDifferentOrder_THEN_SameAssignment(t *testing.T) {
+	h1 := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	h2 := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h1.AddNode("server1")
+	h1.AddNode("server2")
+	h1.AddNode("server3")
+
+	h2.AddNode("server3")
+	h2.AddNode("server1")
+	h2.AddNode("server2")
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		expected, _ := h1.Hash(key, 0)
+		actual, _ := h2.Hash(key, 0)
+
+		if expected != actual {
+			t.Errorf("Expected key `%s` to be assigned to `%s`, but got `%s`", key, expected, actual)
+		}
+	}
+}
+
+func TestWHEN_AddAndRemoveNode_THEN_ChangedSlotsReported(t *testing.T) {
+	h := &MaglevHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server0")
+	if h.ChangedSlots() != DefaultTableSize {
+		t.Errorf("Expected all the %d slots to change owner, but got %d", DefaultTableSize, h.ChangedSlots())
+	}
+
+	for i := 1; i < 10; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	// the new node takes about 1/10th of the slots, with a small disruption for the others
+	changed := h.ChangedSlots()
+	if changed < DefaultTableSize/10 || changed > DefaultTableSize/5 {
+		t.Errorf("Expected about %d slots to change owner, but got %d", DefaultTableSize/10, changed)
+	}
+
+	owned := 0
+	for i := range h.snapshot().lookup {
+		if h.snapshot().ownerOf(i) == "server5" {
+			owned++
+		}
+	}
+
+	h.RemoveNode("server5")
+
+	changed = h.ChangedSlots()
+	if changed < owned || changed > 2*owned {
+		t.Errorf("Expected about %d slots to change owner, but got %d", owned, changed)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if node, _ := h.Hash(fmt.Sprintf("key-%d", i), 0); node == "server5" {
+			t.Errorf("Expected key-%d to not be assigned to the removed node", i)
+		}
+	}
+}
+
+func TestWHEN_NoLogger_THEN_HashDoesNotBuildLogRecords(t *testing.T) {
+	h := &MaglevHashing{}
+	h.AddNode("node1")
+	h.AddNode("node2")
+
+	// the key is only copied once, to be hashed
+	if allocs := testing.AllocsPerRun(100, func() { h.Hash("key-1", 0) }); allocs > 1 {
+		t.Errorf("Expected Hash to allocate at most once, but got %f allocations", allocs)
+	}
+}
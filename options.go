@@ -0,0 +1,185 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hasherprovider
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	hashfunc "github.com/kounkou/hasherprovider/hashfunc"
+)
+
+// Options are given to the factory of an algorithm to create a new hasher. They are
+// set by the provider, then by the Option given to GetHasher. The replicas, nodes
+// and seed are applied by GetHasher once the hasher was created, through the
+// capability interfaces, so that the factories only read the logger and hash function
+type Options struct {
+	// Logger is never nil, and already carries the name of the algorithm
+	Logger *slog.Logger
+	// HashFunc is nil when the algorithm should use its own default hash function
+	HashFunc hashfunc.HashFunc
+	replicas int
+	nodes    []string
+	seed     *int64
+	// hashFunc is set when the hash function was given by WithHashFunc, and not by
+	// the provider
+	hashFunc bool
+}
+
+// Option configures the hasher returned by GetHasher. An Option returns an error
+// when its value is invalid, before the hasher is created
+type Option func(opts *Options) error
+
+// WithReplicas set the replicas of a ReplicatedHasher, before its nodes are added.
+// The number of replicas must be positive non 0
+func WithReplicas(replicas int) Option {
+	return func(opts *Options) error {
+		if replicas <= 0 {
+			return fmt.Errorf("Expected replicas to be positive non 0, but got %d", replicas)
+		}
+
+		opts.replicas = replicas
+
+		return nil
+	}
+}
+
+// WithNodes adds the given nodes to a MembershipHasher, in the given order. The
+// nodes must be non-empty and distinct
+func WithNodes(nodes ...string) Option {
+	return func(opts *Options) error {
+		seen := make(map[string]bool, len(nodes))
+
+		for _, node := range nodes {
+			if len(node) == 0 {
+				return errors.New("Expected nodes to be non-empty")
+			}
+
+			if seen[node] {
+				return fmt.Errorf("Expected nodes to be distinct, but got %q twice", node)
+			}
+			seen[node] = true
+		}
+
+		opts.nodes = append([]string(nil), nodes...)
+
+		return nil
+	}
+}
+
+// WithHashFunc set the hash function of the hasher, instead of the hash function of
+// the provider. It is not supported by the algorithms which do not hash the keys
+func WithHashFunc(hashFunc hashfunc.HashFunc) Option {
+	return func(opts *Options) error {
+		if hashFunc == nil {
+			return errors.New("Expected hash function to be non-nil")
+		}
+
+		opts.HashFunc = hashFunc
+		opts.hashFunc = true
+
+		return nil
+	}
+}
+
+// WithSeed seeds the source of a hasher drawing random numbers, such as Random
+// hashing, so that it returns the same nodes for the same sequence of calls
+func WithSeed(seed int64) Option {
+	return func(opts *Options) error {
+		opts.seed = &seed
+
+		return nil
+	}
+}
+
+// WithLogger set the logger of the hasher, instead of the logger of the provider
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *Options) error {
+		if logger == nil {
+			return errors.New("Expected logger to be non-nil")
+		}
+
+		opts.Logger = logger
+
+		return nil
+	}
+}
+
+// A seededHasher draws random numbers from a source which can be seeded
+type seededHasher interface {
+	Hasher
+	Seed(seed int64)
+}
+
+// Private function not exported checking the hasher of the given algorithm supports
+// the options, so that no option is applied unless all of them can be
+func (opts *Options) check(hasher Hasher, a algorithm) error {
+	unsupported := func(operation string) error {
+		return &UnsupportedError{Hasher: fmt.Sprintf("%T", hasher), Operation: operation}
+	}
+
+	if _, ok := hasher.(ReplicatedHasher); opts.replicas > 0 && !ok {
+		return unsupported("WithReplicas")
+	}
+
+	if _, ok := hasher.(MembershipHasher); len(opts.nodes) > 0 && !ok {
+		return unsupported("WithNodes")
+	}
+
+	if _, ok := hasher.(seededHasher); opts.seed != nil && !ok {
+		return unsupported("WithSeed")
+	}
+
+	if opts.hashFunc && a.keyless {
+		return unsupported("WithHashFunc")
+	}
+
+	return nil
+}
+
+// Private function not exported applying the options to the hasher, the replicas
+// first so that the nodes are added with their replicas
+func (opts *Options) apply(hasher Hasher, a algorithm) error {
+	if err := opts.check(hasher, a); err != nil {
+		return err
+	}
+
+	if opts.replicas > 0 {
+		if err := hasher.(ReplicatedHasher).SetReplicas(opts.replicas); err != nil {
+			return err
+		}
+	}
+
+	if opts.seed != nil {
+		hasher.(seededHasher).Seed(*opts.seed)
+	}
+
+	for _, node := range opts.nodes {
+		if err := hasher.(MembershipHasher).AddNode(node); err != nil {
+			return fmt.Errorf("adding node %q: %w", node, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package hasherprovider
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/kounkou/hasherprovider/consistent"
+	"github.com/kounkou/hasherprovider/hashfunc"
+)
+
+func TestWHEN_OptionsGiven_THEN_HasherConfigured(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	hasher, err := hp.GetHasher(CONSISTENT_HASHING,
+		WithNodes("server1", "server2", "server3"),
+		WithReplicas(10),
+		WithHashFunc(hashfunc.XXHash64),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error for valid options: %v", err)
+	}
+
+	// the replicas are set before the nodes are added, whatever the order of the options
+	ring := hasher.(*consistent.ConsistentHashing)
+	if len(ring.Tokens()) != 30 {
+		t.Errorf("Expected 30 tokens in the ring, but got %d", len(ring.Tokens()))
+	}
+
+	if ring.HashFunc != hashfunc.XXHash64 {
+		t.Errorf("Expected the hash function of the options, but got %v", ring.HashFunc)
+	}
+
+	if result, _ := hasher.Hash("key", 0); result == "" {
+		t.Error("Expected key to be assigned to a node, but got an empty node")
+	}
+}
+
+func TestWHEN_LoggerGiven_THEN_LoggerUsedByHasher(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	hasher, err := hp.GetHasher(RENDEZVOUS_HASHING, WithLogger(logger), WithNodes("server1"))
+	if err != nil {
+		t.Fatalf("Unexpected error for valid options: %v", err)
+	}
+
+	hasher.Hash("key", 0)
+
+	for _, expected := range []string{"msg=AddNode algorithm=rendezvous node=server1", "msg=Hash algorithm=rendezvous key_hash="} {
+		if !bytes.Contains(buffer.Bytes(), []byte(expected)) {
+			t.Errorf("Expected the hasher to log %q with the logger of the options, but got %q", expected, buffer.String())
+		}
+	}
+
+	// the keys are never logged as is
+	if bytes.Contains(buffer.Bytes(), []byte("key ")) || bytes.Contains(buffer.Bytes(), []byte("=key")) {
+		t.Errorf("Expected the key to not be logged, but got %q", buffer.String())
+	}
+}
+
+func TestWHEN_SeedGiven_THEN_SameNodes(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	first, _ := hp.GetHasher(RANDOM_HASHING, WithSeed(42), WithNodes("server1", "server2", "server3"))
+	second, _ := hp.GetHasher(RANDOM_HASHING, WithSeed(42), WithNodes("server1", "server2", "server3"))
+
+	for i := 0; i < 100; i++ {
+		a, _ := first.Hash(fmt.Sprintf("event-%d", i), 0)
+		b, _ := second.Hash(fmt.Sprintf("event-%d", i), 0)
+
+		if a != b {
+			t.Errorf("Expected hashers with the same seed to return the same node, but got %s and %s", a, b)
+		}
+	}
+}
+
+func TestWHEN_InvalidOptions_THEN_ErrorReturned(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	invalid := map[string]Option{
+		"replicas":        WithReplicas(0),
+		"negative":        WithReplicas(-1),
+		"empty node":      WithNodes("server1", ""),
+		"duplicate nodes": WithNodes("server1", "server1"),
+		"hash function":   WithHashFunc(nil),
+		"logger":          WithLogger(nil),
+	}
+
+	for name, opt := range invalid {
+		if hasher, err := hp.GetHasher(CONSISTENT_HASHING, opt); err == nil || hasher != nil {
+			t.Errorf("Expected non-nil error as option %s is invalid but got nil", name)
+		}
+	}
+}
+
+func TestWHEN_UnsupportedOptions_THEN_UnsupportedErrorReturned(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	unsupported := []struct {
+		algo Algorithm
+		opt  Option
+	}{
+		{JUMP_HASHING, WithNodes("server1")},
+		{MAGLEV_HASHING, WithReplicas(4)},
+		{CONSISTENT_HASHING, WithSeed(42)},
+		{RANDOM_HASHING, WithHashFunc(hashfunc.XXHash64)},
+	}
+
+	for _, u := range unsupported {
+		hasher, err := hp.GetHasher(u.algo, u.opt)
+
+		var unsupportedErr *UnsupportedError
+		if hasher != nil || !errors.Is(err, ErrUnsupported) || !errors.As(err, &unsupportedErr) {
+			t.Errorf("Expected an UnsupportedError for algorithm %s, but got %v", u.algo, err)
+		}
+	}
+}
+
+func TestWHEN_NodesWithoutReplicas_THEN_DefaultReplicasUsed(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	hasher, err := hp.GetHasher(CONSISTENT_HASHING, WithNodes("server1", "server2"))
+	if err != nil {
+		t.Fatalf("Unexpected error for valid options: %v", err)
+	}
+
+	if tokens := len(hasher.(*consistent.ConsistentHashing).Tokens()); tokens != 2*consistent.DefaultReplicas {
+		t.Errorf("Expected %d tokens in the ring, but got %d", 2*consistent.DefaultReplicas, tokens)
+	}
+
+	// nodes added after GetHasher also get the default replicas
+	hasher, _ = hp.GetHasher(CONSISTENT_HASHING)
+	if err := AddNode(hasher, "a"); err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	if result, err := hasher.Hash("k", 0); result != "a" || err != nil {
+		t.Errorf("Expected key to be assigned to `a`, but got `%s` and %v", result, err)
+	}
+}
+
+func TestWHEN_ProviderHashFuncWithKeylessAlgorithm_THEN_NoError(t *testing.T) {
+	hp := HasherProvider{
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		HashFunc: hashfunc.CRC32,
+	}
+
+	// the hash function of the provider is only used by the algorithms hashing the keys
+	if _, err := hp.GetHasher(RANDOM_HASHING, WithSeed(42)); err != nil {
+		t.Errorf("Unexpected error for algorithm %s: %v", RANDOM_HASHING, err)
+	}
+}
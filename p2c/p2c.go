@@ -0,0 +1,267 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package p2c
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+// With the power of two choices, every key is given two candidate nodes by two
+// independent hashes of the key, and is routed to the less loaded of the two. The
+// load of the nodes is reported by the caller with Inc and Done. Picking the best of
+// two candidates is enough to bring the maximum load close to the average, where a
+// single random choice leaves some nodes with a much higher load.
+//
+// P2CHashing is safe for concurrent use. The nodes are copied on change and published
+// atomically, so that lookups never take a lock. The loads are updated atomically.
+
+type P2CHashing struct {
+	Logger   *slog.Logger
+	HashFunc hashfunc.HashFunc
+	mu       sync.Mutex
+	nodes    atomic.Pointer[nodes]
+}
+
+// The nodes are an immutable snapshot. The loads are shared between snapshots, so
+// that the load of a node survives the changes of the other nodes
+type nodes struct {
+	names []string
+	loads map[string]*atomic.Int64
+}
+
+// Nodes returns the nodes the keys are routed to
+func (h *P2CHashing) Nodes() []string {
+	return append([]string(nil), h.snapshot().names...)
+}
+
+// AddNode will add a node or entity to the set of nodes, with no load.
+// Adding a node which is already present has no effect
+func (h *P2CHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
+
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	if _, ok := current.loads[node]; ok {
+		h.logger().Warn("AddNode node already present", "node", node)
+		return nil
+	}
+
+	next := &nodes{
+		names: make([]string, 0, len(current.names)+1),
+		loads: make(map[string]*atomic.Int64, len(current.loads)+1),
+	}
+	next.names = append(next.names, current.names...)
+	next.names = append(next.names, node)
+	for name, load := range current.loads {
+		next.loads[name] = load
+	}
+	next.loads[node] = new(atomic.Int64)
+
+	h.nodes.Store(next)
+
+	return nil
+}
+
+// RemoveNode will remove a node or entity, and its load, from the set of nodes.
+// Removing a node which is not present has no effect
+func (h *P2CHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	if _, ok := current.loads[node]; !ok {
+		h.logger().Warn("RemoveNode unknown node", "node", node)
+		return nil
+	}
+
+	next := &nodes{
+		names: make([]string, 0, len(current.names)-1),
+		loads: make(map[string]*atomic.Int64, len(current.loads)-1),
+	}
+	for _, name := range current.names {
+		if name != node {
+			next.names = append(next.names, name)
+			next.loads[name] = current.loads[name]
+		}
+	}
+
+	h.nodes.Store(next)
+
+	return nil
+}
+
+// Inc increments the load of the given node, when a request is assigned to it
+func (h *P2CHashing) Inc(node string) {
+	load, ok := h.snapshot().loads[node]
+	if !ok {
+		h.logger().Warn("Inc unknown node", "node", node)
+		return
+	}
+
+	load.Add(1)
+}
+
+// Done decrements the load of the given node, when a request assigned to it finished
+func (h *P2CHashing) Done(node string) {
+	load, ok := h.snapshot().loads[node]
+	if !ok {
+		h.logger().Warn("Done unknown node", "node", node)
+		return
+	}
+
+	for {
+		current := load.Load()
+		if current == 0 {
+			h.logger().Warn("Done node without load", "node", node)
+			return
+		}
+
+		if load.CompareAndSwap(current, current-1) {
+			return
+		}
+	}
+}
+
+// Loads returns the current load of every node
+func (h *P2CHashing) Loads() map[string]int64 {
+	current := h.snapshot()
+
+	loads := make(map[string]int64, len(current.loads))
+	for node, load := range current.loads {
+		loads[node] = load.Load()
+	}
+
+	return loads
+}
+
+// GetCandidates will return the two distinct candidate nodes of the given key. With
+// a single node, both candidates are that node
+func (h *P2CHashing) GetCandidates(key string) (string, string, error) {
+	if len(key) == 0 {
+		h.logger().Error("GetCandidates failed", logging.KeyHash(key))
+		return "", "", errors.New("Expected key to be non-empty")
+	}
+
+	current := h.snapshot()
+
+	switch len(current.names) {
+	case 0:
+		return "", "", nil
+	case 1:
+		return current.names[0], current.names[0], nil
+	}
+
+	first, second := h.computeCandidates(key, len(current.names))
+
+	return current.names[first], current.names[second], nil
+}
+
+// Private function not exported returning the current nodes, which must NOT be changed
+func (h *P2CHashing) snapshot() *nodes {
+	if n := h.nodes.Load(); n != nil {
+		return n
+	}
+	return &nodes{loads: map[string]*atomic.Int64{}}
+}
+
+// Private function not exported to be able to compute the indexes of the two
+// candidates among n nodes, with n greater than 1. The second candidate is drawn
+// among the n - 1 other nodes so that the candidates are always distinct
+func (h *P2CHashing) computeCandidates(key string, n int) (int, int) {
+	first, _ := bits.Mul64(h.computeHash(key, 0), uint64(n))
+	second, _ := bits.Mul64(h.computeHash(key, 1), uint64(n-1))
+
+	if second >= first {
+		second++
+	}
+
+	return int(first), int(second)
+}
+
+// Private function not exported to be able to compute the hash of the key for the
+// given choice, using FNV-1a 64 bits by default. The hash is finalized with a mixing
+// step so that both choices spread over the whole 64 bits range
+func (h *P2CHashing) computeHash(key string, choice byte) uint64 {
+	hashFunc := h.HashFunc
+	if hashFunc == nil {
+		hashFunc = hashfunc.FNV1a64
+	}
+
+	data := make([]byte, 0, len(key)+2)
+	data = append(data, key...)
+	data = append(data, 0, choice)
+
+	return hashfunc.Mix64(hashFunc.Sum64(data))
+}
+
+// Hash hashes the given input twice to find its two candidate nodes.
+// It returns the less loaded candidate, or the first one when both have the same
+// load, to which the uuid will be assigned. The load is NOT incremented, the caller
+// reports it with Inc and Done
+func (h *P2CHashing) Hash(uuid string, _ int) (string, error) {
+	if len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid))
+		return "", errors.New("Expected uuid to be non-empty")
+	}
+
+	first, second, err := h.GetCandidates(uuid)
+	if err != nil || first == second {
+		return first, err
+	}
+
+	node := first
+
+	loads := h.snapshot().loads
+	if a, b := loads[first], loads[second]; a != nil && b != nil && b.Load() < a.Load() {
+		node = second
+	}
+
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("Hash", logging.KeyHash(uuid), "node", node)
+	}
+
+	return node, nil
+}
+
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *P2CHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
+}
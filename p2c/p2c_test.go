@@ -0,0 +1,149 @@
+package p2c
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_HashFunctionCalledWithNullEvent_THEN_ErrorReturned(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	_, err := h.Hash("", 0)
+	if err == nil {
+		t.Error("Expected non-nil error as event is empty but got nil")
+	}
+}
+
+func TestWHEN_noNodeAdded_THEN_ReturnEmptyString(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	result, err := h.Hash("test", 0)
+
+	if err != nil || len(result) != 0 {
+		t.Errorf("Expected no error and an empty node, but got `%s` and %v", result, err)
+	}
+}
+
+func TestWHEN_AddNodeCalledTwice_THEN_NodeAddedOnce(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("node1")
+	h.AddNode("node1")
+
+	if len(h.Nodes()) != 1 {
+		t.Errorf("Expected 1 node, but got %d", len(h.Nodes()))
+	}
+
+	if result, _ := h.Hash("test", 0); result != "node1" {
+		t.Errorf("Expected the only node `node1`, but got `%s`", result)
+	}
+}
+
+func TestWHEN_GetCandidates_THEN_CandidatesDistinctAndStable(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for i := 0; i < 5; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		first, second, err := h.GetCandidates(key)
+		if err != nil || first == second {
+			t.Fatalf("Expected two distinct candidates for `%s`, but got `%s`, `%s` and %v", key, first, second, err)
+		}
+
+		again, _, _ := h.GetCandidates(key)
+		if again != first {
+			t.Errorf("Expected the candidates of `%s` to be stable, but got `%s` then `%s`", key, first, again)
+		}
+	}
+}
+
+func TestWHEN_FirstCandidateLoaded_THEN_SecondCandidateReturned(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	first, second, _ := h.GetCandidates("key")
+
+	if result, _ := h.Hash("key", 0); result != first {
+		t.Errorf("Expected the first candidate `%s` without load, but got `%s`", first, result)
+	}
+
+	h.Inc(first)
+
+	if result, _ := h.Hash("key", 0); result != second {
+		t.Errorf("Expected the less loaded candidate `%s`, but got `%s`", second, result)
+	}
+
+	h.Done(first)
+	h.Done(first)
+
+	if loads := h.Loads(); loads[first] != 0 {
+		t.Errorf("Expected the load of `%s` to never go below 0, but got %d", first, loads[first])
+	}
+}
+
+func TestWHEN_RequestsRouted_THEN_MaxLoadCloseToAverage(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	nodes := 10
+	for i := 0; i < nodes; i++ {
+		h.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	requests := 10000
+	for i := 0; i < requests; i++ {
+		node, _ := h.Hash(fmt.Sprintf("request-%d", i), 0)
+		h.Inc(node)
+	}
+
+	average := int64(requests / nodes)
+	for node, load := range h.Loads() {
+		if load > average+average/10 {
+			t.Errorf("Expected the load of `%s` to be close to %d, but got %d", node, average, load)
+		}
+	}
+}
+
+func TestWHEN_RemoveNode_THEN_LoadsOfOtherNodesKept(t *testing.T) {
+	h := &P2CHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.Inc("server1")
+	h.Inc("server2")
+
+	h.RemoveNode("server2")
+
+	loads := h.Loads()
+	if len(loads) != 1 || loads["server1"] != 1 {
+		t.Errorf("Expected only the load of server1 to be kept, but got %v", loads)
+	}
+
+	for i := 0; i < 100; i++ {
+		if result, _ := h.Hash(fmt.Sprintf("key-%d", i), 0); result != "server1" {
+			t.Errorf("Expected the remaining node server1, but got `%s`", result)
+		}
+	}
+}
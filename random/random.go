@@ -23,51 +23,113 @@
 package random
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
-	"log"
+	"log/slog"
 	"math/rand"
 	"strconv"
-	"time"
+	"sync"
+
+	"github.com/kounkou/hasherprovider/internal/logging"
 )
 
+// RandomHashing is safe for concurrent use. Each instance draws the shards from its
+// own source, so that seeding an instance never changes the other instances nor the
+// global source of math/rand.
+
 type RandomHashing struct {
-	Logger *log.Logger
+	Logger *slog.Logger
+	mu     sync.Mutex
+	rng    *rand.Rand
+	nodes  *alias
+}
+
+// Seed resets the source of the hasher to a deterministic source seeded with the
+// given seed. Two hashers seeded with the same seed return the same shards for the
+// same sequence of calls, which makes replayed traffic route identically
+func (h *RandomHashing) Seed(seed int64) {
+	h.SetSource(rand.NewSource(seed))
+}
+
+// SetSource set the source the shards are drawn from, for example NewCryptoSource.
+// The source is only used by this hasher, under its lock
+func (h *RandomHashing) SetSource(source rand.Source) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.rng = rand.New(source)
 }
 
 // Random hashing is used to distribute the uuid's associated (example events...)
 // without any structure. It's therefore the least efficient way to distribute the
-// uuid's across a set of entity (for example servers)
-func (h RandomHashing) Hash(uuid string, shards int) (string, error) {
-	if shards == 0 || len(uuid) == 0 {
-		h.Logger.Println("[ERROR] Random Hashing ", uuid, " failed with ", shards, " shards")
+// uuid's across a set of entity (for example servers).
+// Once nodes were added, it returns a node drawn proportionally to its weight,
+// otherwise a shard index. Unless Seed or SetSource was called, the source of the
+// hasher is seeded once from crypto/rand on first use
+func (h *RandomHashing) Hash(uuid string, shards int) (string, error) {
+	if len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid), "shards", shards)
+		return "", errors.New("Expected uuid to be non-empty")
+	}
+
+	if node, ok := h.pick(); ok {
+		return node, nil
+	}
+
+	if shards <= 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid), "shards", shards)
 		return "", errors.New("Expected shards to be positive non 0")
 	}
 
-	rand.Seed(time.Now().UnixNano())
+	return strconv.Itoa(h.intn(shards)), nil
+}
+
+// Private function not exported drawing a number in [0, n) from the source of the
+// hasher
+func (h *RandomHashing) intn(n int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.source().Intn(n)
+}
+
+// Private function not exported returning the source of the hasher, which is
+// created on first use. It must be called with the lock held
+func (h *RandomHashing) source() *rand.Rand {
+	if h.rng == nil {
+		h.rng = rand.New(rand.NewSource(int64(cryptoSource{}.Uint64())))
+	}
+	return h.rng
+}
 
-	return strconv.Itoa(rand.Intn(shards)), nil
+// NewCryptoSource returns a source reading from crypto/rand. It can NOT be seeded,
+// and is slower than the default source, but its values are unpredictable
+func NewCryptoSource() rand.Source64 {
+	return cryptoSource{}
 }
 
-// Implemented for convenience, Randomhashing does NOT support AddNode as the Randomhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the random Hashing algorithm
-func (h RandomHashing) AddNode(_ string) {
-	panic("AddNode method is not implemented for RandomHashing")
+type cryptoSource struct{}
+
+// Seed has no effect, a crypto source can NOT be seeded
+func (cryptoSource) Seed(int64) {}
+
+// Int63 returns a non-negative 63 bits integer read from crypto/rand
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
 }
 
-// Implemented for convenience, Randomhashing does NOT support RemoveNode as the Randomhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the random Hashing algorithm
-func (h RandomHashing) RemoveNode(_ string) {
-	panic("RemoveNode method is not implemented for RandomHashing")
+// Uint64 returns a 64 bits integer read from crypto/rand
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("random: crypto/rand failed: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
 }
 
-// Implemented for convenience, Randomhashing does NOT support SetReplicas as the Randomhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the random Hashing algorithm
-func (h *RandomHashing) SetReplicas(_ int) {
-	panic("SetReplicas method is not implemented for RandomHashing")
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *RandomHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
 }
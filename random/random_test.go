@@ -1,8 +1,10 @@
 package random
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
 	"testing"
 )
 
@@ -13,7 +15,7 @@ type Tuple struct {
 
 func TestWHEN_HashFunctionCalledWithNullEvent_THEN_NullPointerExceptionThrown(t *testing.T) {
 	hasher := &RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	event := ""
@@ -27,7 +29,7 @@ func TestWHEN_HashFunctionCalledWithNullEvent_THEN_NullPointerExceptionThrown(t
 
 func TestWHEN_HashFunctionCalledWithNullShards_THEN_NullPointerExceptionThrown(t *testing.T) {
 	hasher := &RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	event := "1"
@@ -41,7 +43,7 @@ func TestWHEN_HashFunctionCalledWithNullShards_THEN_NullPointerExceptionThrown(t
 
 func TestWHEN_HashFunctionCalledWithKeyAndShardNumbers_THEN_ResultMatchesExpected(t *testing.T) {
 	hasher := &RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	eventList := []Tuple{
@@ -62,55 +64,53 @@ func TestWHEN_HashFunctionCalledWithKeyAndShardNumbers_THEN_ResultMatchesExpecte
 	}
 }
 
-func TestRandomHashing_AddNode(t *testing.T) {
-	h := RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+func TestWHEN_SameSeed_THEN_SameShards(t *testing.T) {
+	first := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	second := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
-	expectedError := "AddNode method is not implemented for RandomHashing"
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("AddNode did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("AddNode panicked with error message '%s', but expected '%s'", r, expectedError)
-		}
-	}()
+	first.Seed(42)
+	second.Seed(42)
 
-	h.AddNode("node")
-}
+	shards := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		a, _ := first.Hash(fmt.Sprintf("event-%d", i), 16)
+		b, _ := second.Hash(fmt.Sprintf("event-%d", i), 16)
 
-func TestRandomHashing_RemoveNode(t *testing.T) {
-	h := RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		if a != b {
+			t.Errorf("Expected hashers seeded with the same seed to return the same shard, but got %s and %s", a, b)
+		}
+		shards = append(shards, a)
 	}
 
-	expectedError := "RemoveNode method is not implemented for RandomHashing"
-
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("RemoveNode did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("RemoveNode panicked with error message '%s', but expected '%s'", r, expectedError)
+	// seeding again replays the same shards
+	first.Seed(42)
+	for i := 0; i < 100; i++ {
+		if result, _ := first.Hash(fmt.Sprintf("event-%d", i), 16); result != shards[i] {
+			t.Errorf("Expected the shards to be replayed after Seed, but got %s instead of %s", result, shards[i])
 		}
-	}()
-
-	h.RemoveNode("node")
+	}
 }
 
-func TestRandomHashing_SetReplicas(t *testing.T) {
-	h := RandomHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+func TestWHEN_CryptoSource_THEN_ShardsInRange(t *testing.T) {
+	hasher := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	expectedError := "SetReplicas method is not implemented for RandomHashing"
+	hasher.SetSource(NewCryptoSource())
+
+	for i := 0; i < 1000; i++ {
+		result, _ := hasher.Hash("event", 7)
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("SetReplicas did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("SetReplicas panicked with error message '%s', but expected '%s'", r, expectedError)
+		if shard, err := strconv.Atoi(result); err != nil || shard < 0 || shard >= 7 {
+			t.Errorf("Hash(event, 7) = %s; expected a shard in [0, 7)", result)
 		}
-	}()
+	}
 
-	h.SetReplicas(4)
+	if _, err := hasher.Hash("event", -1); err == nil {
+		t.Error("Expected non-nil error as shards number is negative but got nil")
+	}
 }
@@ -0,0 +1,288 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package random
+
+import (
+	"errors"
+	"math"
+)
+
+// With weighted nodes, Hash returns a node drawn with a probability proportional to
+// its weight, so that traffic splits such as 95/5 for a canary are expressed directly
+// with the names of the nodes. The nodes are sampled in constant time with the alias
+// method (Vose), whose table is rebuilt on every change of the nodes.
+
+// An alias table is immutable. Slot i is drawn uniformly, then keeps node i with
+// probability prob[i] and gives its turn to node alias[i] otherwise
+type alias struct {
+	names   []string
+	weights []float64
+	prob    []float64
+	alias   []int
+}
+
+// AddNode will add a node or entity with a weight of 1.
+// Adding a node which is already present has no effect
+func (h *RandomHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
+
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.indexOf(node) >= 0 {
+		h.logger().Warn("AddNode node already present", "node", node)
+		return nil
+	}
+
+	h.setWeight(-1, node, 1)
+
+	return nil
+}
+
+// AddWeightedNode will add a node or entity drawn proportionally to its weight.
+// The weight must be positive non 0
+func (h *RandomHashing) AddWeightedNode(node string, weight float64) error {
+	h.logger().Info("AddWeightedNode", "node", node, "weight", weight)
+
+	if len(node) == 0 {
+		h.logger().Error("AddWeightedNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	if err := h.validateWeight(weight); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.indexOf(node) >= 0 {
+		h.logger().Error("AddWeightedNode node already present", "node", node)
+		return errors.New("Expected node to not be present, use UpdateWeight instead")
+	}
+
+	h.setWeight(-1, node, weight)
+
+	return nil
+}
+
+// UpdateWeight changes the weight of a node already present
+func (h *RandomHashing) UpdateWeight(node string, weight float64) error {
+	h.logger().Info("UpdateWeight", "node", node, "weight", weight)
+
+	if err := h.validateWeight(weight); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	idx := h.indexOf(node)
+	if idx < 0 {
+		h.logger().Error("UpdateWeight unknown node", "node", node)
+		return errors.New("Expected node to be present")
+	}
+
+	h.setWeight(idx, node, weight)
+
+	return nil
+}
+
+// RemoveNode will remove a node or entity, the remaining nodes keep their weight.
+// Removing a node which is not present has no effect
+func (h *RandomHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.nodes == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(h.nodes.names))
+	weights := make([]float64, 0, len(h.nodes.weights))
+
+	for i, name := range h.nodes.names {
+		if name != node {
+			names = append(names, name)
+			weights = append(weights, h.nodes.weights[i])
+		}
+	}
+
+	if len(names) == 0 {
+		h.nodes = nil
+		return nil
+	}
+
+	h.nodes = newAlias(names, weights)
+
+	return nil
+}
+
+// Nodes returns the nodes in the order they were added
+func (h *RandomHashing) Nodes() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.nodes == nil {
+		return nil
+	}
+	return append([]string(nil), h.nodes.names...)
+}
+
+// Weight returns the weight of the given node, or 0 when the node is not present
+func (h *RandomHashing) Weight(node string) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if idx := h.indexOf(node); idx >= 0 {
+		return h.nodes.weights[idx]
+	}
+	return 0
+}
+
+// Private function not exported checking the weight is positive non 0 and finite
+func (h *RandomHashing) validateWeight(weight float64) error {
+	if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		h.logger().Error("Invalid weight", "weight", weight)
+		return errors.New("Expected weight to be positive non 0")
+	}
+	return nil
+}
+
+// Private function not exported returning the index of the given node, or -1 when
+// the node is not present. It must be called with the lock held
+func (h *RandomHashing) indexOf(node string) int {
+	if h.nodes != nil {
+		for i, name := range h.nodes.names {
+			if name == node {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Private function not exported to be able to change the weight of the node at the
+// given index, or to add the node when the index is -1, then rebuild the alias
+// table. It must be called with the lock held
+func (h *RandomHashing) setWeight(idx int, node string, weight float64) {
+	var names []string
+	var weights []float64
+
+	if h.nodes != nil {
+		names = append(names, h.nodes.names...)
+		weights = append(weights, h.nodes.weights...)
+	}
+
+	if idx < 0 {
+		names = append(names, node)
+		weights = append(weights, weight)
+	} else {
+		weights[idx] = weight
+	}
+
+	h.nodes = newAlias(names, weights)
+}
+
+// Private function not exported drawing a node proportionally to its weight, and
+// whether there is any node to draw from
+func (h *RandomHashing) pick() (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.nodes == nil {
+		return "", false
+	}
+
+	rng := h.source()
+
+	i := rng.Intn(len(h.nodes.names))
+	if rng.Float64() < h.nodes.prob[i] {
+		return h.nodes.names[i], true
+	}
+	return h.nodes.names[h.nodes.alias[i]], true
+}
+
+// Private function not exported building the alias table of the given nodes with
+// Vose's method. The weights are scaled so that their average is 1, then every slot
+// of a node below 1 is completed by a node above 1
+func newAlias(names []string, weights []float64) *alias {
+	n := len(names)
+
+	t := &alias{
+		names:   names,
+		weights: weights,
+		prob:    make([]float64, n),
+		alias:   make([]int, n),
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+
+	for i, weight := range weights {
+		scaled[i] = weight * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// the remaining slots are full, up to rounding errors
+	for _, i := range append(small, large...) {
+		t.prob[i] = 1
+		t.alias[i] = i
+	}
+
+	return t
+}
@@ -0,0 +1,144 @@
+package random
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestWHEN_NodesAdded_THEN_NodeNameReturned(t *testing.T) {
+	hasher := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	hasher.AddNode("server1")
+	hasher.AddNode("server2")
+	hasher.AddNode("server2")
+
+	if len(hasher.Nodes()) != 2 {
+		t.Errorf("Expected 2 nodes, but got %d", len(hasher.Nodes()))
+	}
+
+	for i := 0; i < 100; i++ {
+		result, err := hasher.Hash(fmt.Sprintf("event-%d", i), 0)
+		if err != nil || (result != "server1" && result != "server2") {
+			t.Errorf("Expected server1 or server2, but got `%s` and %v", result, err)
+		}
+	}
+
+	hasher.RemoveNode("server1")
+	hasher.RemoveNode("server2")
+
+	if _, err := hasher.Hash("event", 0); err == nil {
+		t.Error("Expected non-nil error as shards number is 0 and there are no nodes but got nil")
+	}
+}
+
+func TestWHEN_EmptyUuidWithNodes_THEN_ErrorReturnedAndSourceUnchanged(t *testing.T) {
+	first := &RandomHashing{Logger: slog.New(slog.NewTextHandler(os.Stdout, nil))}
+	second := &RandomHashing{Logger: first.Logger}
+
+	for _, hasher := range []*RandomHashing{first, second} {
+		hasher.Seed(42)
+		hasher.AddNode("server1")
+		hasher.AddNode("server2")
+	}
+
+	if _, err := first.Hash("", 3); err == nil || err.Error() != "Expected uuid to be non-empty" {
+		t.Errorf("Expected the empty uuid to be reported, but got %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		a, _ := first.Hash("event", 0)
+		b, _ := second.Hash("event", 0)
+		if a != b {
+			t.Fatalf("Expected the failed call to leave the source unchanged, but got %s instead of %s", a, b)
+		}
+	}
+}
+
+func TestWHEN_CanarySplit_THEN_NodesDrawnProportionallyToWeight(t *testing.T) {
+	hasher := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	hasher.Seed(42)
+	hasher.AddWeightedNode("stable", 95)
+	hasher.AddWeightedNode("canary", 5)
+
+	draws := 100000
+	counts := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		result, _ := hasher.Hash("event", 0)
+		counts[result]++
+	}
+
+	if fraction := float64(counts["canary"]) / float64(draws); fraction < 0.045 || fraction > 0.055 {
+		t.Errorf("Expected about 5%% of the events on the canary, but got %f", fraction)
+	}
+
+	hasher.UpdateWeight("canary", 95)
+
+	counts = make(map[string]int)
+	for i := 0; i < draws; i++ {
+		result, _ := hasher.Hash("event", 0)
+		counts[result]++
+	}
+
+	if fraction := float64(counts["canary"]) / float64(draws); fraction < 0.49 || fraction > 0.51 {
+		t.Errorf("Expected about 50%% of the events on the canary, but got %f", fraction)
+	}
+}
+
+func TestWHEN_AliasTableBuilt_THEN_ProbabilitiesMatchWeights(t *testing.T) {
+	weights := []float64{1, 2, 3, 0.5, 10, 7.25}
+	names := []string{"a", "b", "c", "d", "e", "f"}
+
+	table := newAlias(names, weights)
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	// every slot is drawn with probability 1/n, then kept or given to its alias
+	probabilities := make([]float64, len(names))
+	for i := range names {
+		probabilities[i] += table.prob[i] / float64(len(names))
+		probabilities[table.alias[i]] += (1 - table.prob[i]) / float64(len(names))
+	}
+
+	for i, weight := range weights {
+		if math.Abs(probabilities[i]-weight/total) > 1e-9 {
+			t.Errorf("Expected node %s to be drawn with probability %f, but got %f", names[i], weight/total, probabilities[i])
+		}
+	}
+}
+
+func TestWHEN_InvalidWeight_THEN_ErrorReturned(t *testing.T) {
+	hasher := &RandomHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	for _, weight := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if err := hasher.AddWeightedNode("server1", weight); err == nil {
+			t.Errorf("Expected non-nil error as weight %f is invalid but got nil", weight)
+		}
+	}
+
+	hasher.AddWeightedNode("server1", 1)
+
+	if err := hasher.AddWeightedNode("server1", 2); err == nil {
+		t.Error("Expected non-nil error as node is already present but got nil")
+	}
+
+	if err := hasher.UpdateWeight("server2", 2); err == nil {
+		t.Error("Expected non-nil error as node is not present but got nil")
+	}
+
+	if hasher.Weight("server1") != 1 {
+		t.Errorf("Expected the weight of server1 to be unchanged, but got %f", hasher.Weight("server1"))
+	}
+}
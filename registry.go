@@ -0,0 +1,154 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hasherprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	consistent "github.com/kounkou/hasherprovider/consistent"
+	jump "github.com/kounkou/hasherprovider/jump"
+	maglev "github.com/kounkou/hasherprovider/maglev"
+	p2c "github.com/kounkou/hasherprovider/p2c"
+	random "github.com/kounkou/hasherprovider/random"
+	rendezvous "github.com/kounkou/hasherprovider/rendezvous"
+	uniform "github.com/kounkou/hasherprovider/uniform"
+)
+
+// The registry holds every algorithm GetHasher can return. The built-in algorithms
+// are registered first, in the order of their constants, so that the Algorithm of an
+// algorithm is its index in the registry. An algorithm registered with Register is
+// given the next index, returned by ParseAlgorithm.
+
+// Factory creates a new hasher of an algorithm from the given options
+type Factory func(opts Options) (Hasher, error)
+
+// ErrAlreadyRegistered is matched by errors.Is when an algorithm is registered
+// under a name already in use
+var ErrAlreadyRegistered = errors.New("algorithm already registered")
+
+// An algorithm which does not hash the keys, such as Random hashing, has no use for
+// a hash function and does not support WithHashFunc
+type algorithm struct {
+	name    string
+	factory Factory
+	keyless bool
+}
+
+var registry = struct {
+	mu         sync.RWMutex
+	algorithms []algorithm
+	ids        map[string]Algorithm
+}{
+	algorithms: []algorithm{
+		CONSISTENT_HASHING: {"consistent", func(opts Options) (Hasher, error) {
+			return &consistent.ConsistentHashing{
+				Replicas: consistent.DefaultReplicas,
+				Logger:   opts.Logger,
+				HashFunc: opts.HashFunc,
+			}, nil
+		}, false},
+		RANDOM_HASHING: {"random", func(opts Options) (Hasher, error) {
+			return &random.RandomHashing{Logger: opts.Logger}, nil
+		}, true},
+		UNIFORM_HASHING: {"uniform", func(opts Options) (Hasher, error) {
+			return &uniform.UniformHashing{Logger: opts.Logger, HashFunc: opts.HashFunc}, nil
+		}, false},
+		RENDEZVOUS_HASHING: {"rendezvous", func(opts Options) (Hasher, error) {
+			return &rendezvous.RendezvousHashing{Logger: opts.Logger, HashFunc: opts.HashFunc}, nil
+		}, false},
+		JUMP_HASHING: {"jump", func(opts Options) (Hasher, error) {
+			return &jump.JumpHashing{Logger: opts.Logger, HashFunc: opts.HashFunc}, nil
+		}, false},
+		MAGLEV_HASHING: {"maglev", func(opts Options) (Hasher, error) {
+			return &maglev.MaglevHashing{Logger: opts.Logger, HashFunc: opts.HashFunc}, nil
+		}, false},
+		P2C_HASHING: {"p2c", func(opts Options) (Hasher, error) {
+			return &p2c.P2CHashing{Logger: opts.Logger, HashFunc: opts.HashFunc}, nil
+		}, false},
+	},
+}
+
+func init() {
+	registry.ids = make(map[string]Algorithm, len(registry.algorithms))
+	for id, a := range registry.algorithms {
+		registry.ids[a.name] = Algorithm(id)
+	}
+}
+
+// Register adds an algorithm, created by the given factory, which can then be
+// obtained from GetHasherByName, or from GetHasher with the Algorithm returned by
+// ParseAlgorithm. The name is registered in lower case without surrounding spaces,
+// and must be non-empty and not already registered whatever its case
+func Register(name string, factory Factory) error {
+	name = normalizeName(name)
+
+	if len(name) == 0 || factory == nil {
+		return errors.New("Expected name to be non-empty and factory to be non-nil")
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.ids[name]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyRegistered, name)
+	}
+
+	registry.ids[name] = Algorithm(len(registry.algorithms))
+	registry.algorithms = append(registry.algorithms, algorithm{name: name, factory: factory})
+
+	return nil
+}
+
+// Private function not exported returning the name an algorithm is registered and
+// parsed under, in lower case without surrounding spaces
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// List returns the names of the registered algorithms, ordered by Algorithm
+func List() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	names := make([]string, len(registry.algorithms))
+	for id, a := range registry.algorithms {
+		names[id] = a.name
+	}
+
+	return names
+}
+
+// Private function not exported returning the algorithm registered as the given
+// Algorithm, and whether such an algorithm exists
+func lookupAlgorithm(id Algorithm) (algorithm, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if id < 0 || int(id) >= len(registry.algorithms) {
+		return algorithm{}, false
+	}
+	return registry.algorithms[id], true
+}
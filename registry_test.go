@@ -0,0 +1,174 @@
+package hasherprovider
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The registry is global, every test registers algorithms under new names so that
+// the tests can run several times in the same process
+var registrations int
+
+func newName(prefix string) string {
+	registrations++
+	return fmt.Sprintf("%s-%d", prefix, registrations)
+}
+
+type constantHasher struct {
+	node string
+}
+
+func (h *constantHasher) Hash(uuid string, _ int) (string, error) {
+	return h.node, nil
+}
+
+func TestWHEN_AlgorithmRegistered_THEN_ObtainedFromGetHasher(t *testing.T) {
+	var buffer bytes.Buffer
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(&buffer, nil)),
+	}
+
+	name := newName("constant")
+
+	var received Options
+	err := Register(name, func(opts Options) (Hasher, error) {
+		received = opts
+		return &constantHasher{node: "server1"}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error registering a new algorithm: %v", err)
+	}
+
+	algo, err := ParseAlgorithm(name)
+	if err != nil {
+		t.Fatalf("Expected the registered algorithm to be found, but got %v", err)
+	}
+
+	hasher, err := hp.GetHasher(algo)
+	if err != nil {
+		t.Fatalf("Unexpected error for registered algorithm type %d: %v", algo, err)
+	}
+
+	if result, _ := hasher.Hash("key", 0); result != "server1" {
+		t.Errorf("Expected the registered hasher to be used, but got `%s`", result)
+	}
+
+	received.Logger.Info("Created")
+	if expected := "algorithm=" + name; !bytes.Contains(buffer.Bytes(), []byte(expected)) {
+		t.Errorf("Expected the logger of the provider to be given to the factory with %s, but got %q", expected, buffer.String())
+	}
+
+	found := false
+	for _, n := range List() {
+		found = found || n == name
+	}
+	if !found {
+		t.Errorf("Expected the registered algorithm in %v", List())
+	}
+}
+
+func TestWHEN_AlgorithmRegisteredTwice_THEN_ErrorReturned(t *testing.T) {
+	factory := func(opts Options) (Hasher, error) {
+		return &constantHasher{}, nil
+	}
+
+	for _, name := range []string{"consistent", "maglev"} {
+		if err := Register(name, factory); !errors.Is(err, ErrAlreadyRegistered) {
+			t.Errorf("Expected ErrAlreadyRegistered registering %s, but got %v", name, err)
+		}
+	}
+
+	if err := Register("", factory); err == nil {
+		t.Error("Expected non-nil error as name is empty but got nil")
+	}
+
+	if err := Register("nil-factory", nil); err == nil {
+		t.Error("Expected non-nil error as factory is nil but got nil")
+	}
+}
+
+func TestWHEN_FactoryFails_THEN_GetHasherReturnsError(t *testing.T) {
+	hp := HasherProvider{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	name := newName("failing")
+
+	failure := errors.New("no configuration")
+	Register(name, func(opts Options) (Hasher, error) {
+		return nil, failure
+	})
+
+	algo, _ := ParseAlgorithm(name)
+	if _, err := hp.GetHasher(algo); !errors.Is(err, failure) {
+		t.Errorf("Expected the error of the factory, but got %v", err)
+	}
+}
+
+func TestWHEN_List_THEN_BuiltInAlgorithmsOrderedByConstant(t *testing.T) {
+	names := List()
+
+	expected := map[Algorithm]string{
+		CONSISTENT_HASHING: "consistent",
+		RANDOM_HASHING:     "random",
+		UNIFORM_HASHING:    "uniform",
+		RENDEZVOUS_HASHING: "rendezvous",
+		JUMP_HASHING:       "jump",
+		MAGLEV_HASHING:     "maglev",
+		P2C_HASHING:        "p2c",
+	}
+
+	for algo, name := range expected {
+		if names[algo] != name {
+			t.Errorf("Expected algorithm type %d to be %s, but got %s", algo, name, names[algo])
+		}
+
+		if id, err := ParseAlgorithm(name); err != nil || id != algo {
+			t.Errorf("Expected %s to be algorithm type %d, but got %d", name, algo, id)
+		}
+	}
+}
+
+func TestWHEN_AlgorithmRegisteredWithUpperCase_THEN_NameNormalized(t *testing.T) {
+	name := newName(" InHouse")
+
+	if err := Register(name, func(opts Options) (Hasher, error) {
+		return &constantHasher{}, nil
+	}); err != nil {
+		t.Fatalf("Unexpected error registering a new algorithm: %v", err)
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(name))
+
+	var algo Algorithm
+	if err := algo.UnmarshalText([]byte(normalized)); err != nil || algo.String() != normalized {
+		t.Errorf("Expected %s to be read back, but got %s and %v", normalized, algo, err)
+	}
+
+	if err := Register(strings.ToUpper(normalized), func(opts Options) (Hasher, error) {
+		return &constantHasher{}, nil
+	}); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected ErrAlreadyRegistered registering %s in upper case, but got %v", normalized, err)
+	}
+
+	if err := Register("Consistent", func(opts Options) (Hasher, error) {
+		return &constantHasher{}, nil
+	}); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected ErrAlreadyRegistered registering Consistent, but got %v", err)
+	}
+
+	if algo, _ := ParseAlgorithm("Consistent"); algo != CONSISTENT_HASHING {
+		t.Errorf("Expected Consistent to be the built-in algorithm, but got %s", algo)
+	}
+
+	if err := Register("  ", func(opts Options) (Hasher, error) {
+		return &constantHasher{}, nil
+	}); err == nil {
+		t.Error("Expected non-nil error as name is blank but got nil")
+	}
+}
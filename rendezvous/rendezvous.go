@@ -0,0 +1,243 @@
+// MIT License
+//
+// Copyright (c) 2023 Godfrain Jacques Kounkou
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rendezvous
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
+)
+
+// With rendezvous Hashing (also known as highest random weight Hashing), every
+// node computes a weight for the given key and the node with the highest weight
+// owns the key. Adding or removing a node only moves the keys won or lost by that
+// node, without the memory cost of the virtual nodes of the consistent Hashing ring.
+//
+// RendezvousHashing is safe for concurrent use. The nodes are copied on change and
+// published atomically, so that lookups never take a lock.
+
+type RendezvousHashing struct {
+	Logger   *slog.Logger
+	HashFunc hashfunc.HashFunc
+	mu       sync.Mutex
+	nodes    atomic.Pointer[[]string]
+}
+
+// Nodes returns the nodes competing for the keys
+func (h *RendezvousHashing) Nodes() []string {
+	return append([]string(nil), h.snapshot()...)
+}
+
+// AddNode will add a node or entity to the set of nodes competing for the keys.
+// Adding a node which is already present has no effect
+func (h *RendezvousHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
+
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	for _, n := range current {
+		if n == node {
+			h.logger().Warn("AddNode node already present", "node", node)
+			return nil
+		}
+	}
+
+	nodes := make([]string, 0, len(current)+1)
+	nodes = append(nodes, current...)
+	nodes = append(nodes, node)
+
+	h.nodes.Store(&nodes)
+
+	return nil
+}
+
+// RemoveNode will remove a node or entity from the set of nodes. Only the keys
+// previously owned by the removed node will be reassigned. Removing a node which
+// is not present has no effect
+func (h *RendezvousHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot()
+	for i, n := range current {
+		if n == node {
+			nodes := make([]string, 0, len(current)-1)
+			nodes = append(nodes, current[:i]...)
+			nodes = append(nodes, current[i+1:]...)
+
+			h.nodes.Store(&nodes)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Private function not exported returning the current nodes, which must NOT be changed
+func (h *RendezvousHashing) snapshot() []string {
+	if nodes := h.nodes.Load(); nodes != nil {
+		return *nodes
+	}
+	return nil
+}
+
+// GetTopNodes will return the n nodes with the highest weight for the given key,
+// ordered from the highest weight to the lowest. The first node is the owner of the
+// key and the following ones can be used as replicas or fallback nodes.
+// If n is greater than the number of nodes, all the nodes are returned
+func (h *RendezvousHashing) GetTopNodes(key string, n int) ([]string, error) {
+	if len(key) == 0 || n <= 0 {
+		h.logger().Error("GetTopNodes failed", logging.KeyHash(key), "n", n)
+		return nil, errors.New("Expected key to be non-empty and n to be positive non 0")
+	}
+
+	current := h.snapshot()
+
+	if len(current) == 0 {
+		return []string{}, nil
+	}
+
+	if n == 1 {
+		return []string{h.getTopNode(current, key)}, nil
+	}
+
+	type weightedNode struct {
+		node   string
+		weight uint64
+	}
+
+	buf := buffers.Get().(*[]byte)
+	defer buffers.Put(buf)
+
+	weighted := make([]weightedNode, len(current))
+	for i, node := range current {
+		weighted[i] = weightedNode{node, h.computeWeight(buf, node, key)}
+	}
+
+	sort.Slice(weighted, func(i, j int) bool {
+		if weighted[i].weight == weighted[j].weight {
+			return weighted[i].node < weighted[j].node
+		}
+		return weighted[i].weight > weighted[j].weight
+	})
+
+	if n > len(weighted) {
+		n = len(weighted)
+	}
+
+	nodes := make([]string, n)
+	for i := 0; i < n; i++ {
+		nodes[i] = weighted[i].node
+	}
+
+	return nodes, nil
+}
+
+// buffers holds the buffers the node and the key are written to before being hashed,
+// so that computing the weights of a key does not allocate
+var buffers = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
+// Private function not exported returning the node with the highest weight for the
+// given key among the given non-empty nodes, in a single pass over the nodes. On a
+// tie, the node with the lowest name wins, as in GetTopNodes
+func (h *RendezvousHashing) getTopNode(nodes []string, key string) string {
+	buf := buffers.Get().(*[]byte)
+	defer buffers.Put(buf)
+
+	top, topWeight := nodes[0], h.computeWeight(buf, nodes[0], key)
+
+	for _, node := range nodes[1:] {
+		if weight := h.computeWeight(buf, node, key); weight > topWeight || (weight == topWeight && node < top) {
+			top, topWeight = node, weight
+		}
+	}
+
+	return top
+}
+
+// Private function not exported to be able to compute the weight of the key for
+// the given node, writing the node and the key to the given buffer. The hash, FNV-1a
+// 64 bits by default, is finalized with a mixing step so that close keys and node
+// names still spread their weights over the whole 64 bits range
+func (h *RendezvousHashing) computeWeight(buf *[]byte, node string, key string) uint64 {
+	hashFunc := h.HashFunc
+	if hashFunc == nil {
+		hashFunc = hashfunc.FNV1a64
+	}
+
+	data := append((*buf)[:0], node...)
+	data = append(data, 0)
+	data = append(data, key...)
+	*buf = data
+
+	return hashfunc.Mix64(hashFunc.Sum64(data))
+}
+
+// Hash hashes the given input by computing its weight on every node.
+// It returns the node with the highest weight, to which the uuid will be assigned
+func (h *RendezvousHashing) Hash(uuid string, _ int) (string, error) {
+	if len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid))
+		return "", errors.New("Expected uuid to be non-empty")
+	}
+
+	current := h.snapshot()
+
+	if len(current) == 0 {
+		return "", nil
+	}
+
+	node := h.getTopNode(current, uuid)
+
+	// the attributes of the record are only built when it is logged, to not allocate
+	if logger := h.logger(); logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("Hash", logging.KeyHash(uuid), "node", node)
+	}
+
+	return node, nil
+}
+
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *RendezvousHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
+}
@@ -0,0 +1,169 @@
+package rendezvous
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestWHEN_providedWithEmptyUUID_THEN_ReturnError(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("node1")
+
+	result, err := h.Hash("", 0)
+
+	if err == nil || len(result) != 0 {
+		t.Errorf("Expected an error and an empty node, but got `%s`", result)
+	}
+}
+
+func TestWHEN_noNodeAdded_THEN_ReturnEmptyString(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	result, err := h.Hash("test", 0)
+
+	if err != nil || len(result) != 0 {
+		t.Errorf("Expected no error and an empty node, but got `%s` and %v", result, err)
+	}
+}
+
+func TestWHEN_AddNodeCalledTwice_THEN_NodeAddedOnce(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("node1")
+	h.AddNode("node1")
+
+	if len(h.Nodes()) != 1 {
+		t.Errorf("Expected 1 node, but got %d", len(h.Nodes()))
+	}
+}
+
+func TestWHEN_AddNode_THEN_OnlyKeysWonByNewNodeMove(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key], _ = h.Hash(key, 0)
+	}
+
+	h.AddNode("server4")
+
+	moved := 0
+	for key, expected := range before {
+		actual, _ := h.Hash(key, 0)
+		if actual != expected {
+			if actual != "server4" {
+				t.Errorf("Expected key `%s` to stay on `%s` or move to `server4`, but got `%s`", key, expected, actual)
+			}
+			moved++
+		}
+	}
+
+	if moved == 0 || moved > 400 {
+		t.Errorf("Expected about a quarter of the keys to move to the new node, but %d keys moved", moved)
+	}
+}
+
+func TestWHEN_RemoveNode_THEN_OnlyKeysOfRemovedNodeMove(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key], _ = h.Hash(key, 0)
+	}
+
+	h.RemoveNode("server2")
+
+	for key, expected := range before {
+		actual, _ := h.Hash(key, 0)
+		if expected != "server2" && actual != expected {
+			t.Errorf("Expected key `%s` to stay on `%s`, but got `%s`", key, expected, actual)
+		}
+		if actual == "server2" {
+			t.Errorf("Expected key `%s` to not be assigned to the removed node", key)
+		}
+	}
+}
+
+func TestWHEN_GetTopNodes_THEN_DistinctNodesStartingWithOwner(t *testing.T) {
+	h := &RendezvousHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	h.AddNode("server1")
+	h.AddNode("server2")
+	h.AddNode("server3")
+
+	nodes, err := h.GetTopNodes("hello", 2)
+	if err != nil {
+		t.Errorf("Expected no errors to occur but got %s", err)
+	}
+
+	if len(nodes) != 2 || nodes[0] == nodes[1] {
+		t.Errorf("Expected 2 distinct nodes, but got %v", nodes)
+	}
+
+	owner, _ := h.Hash("hello", 0)
+	if nodes[0] != owner {
+		t.Errorf("Expected the first node to be the owner `%s`, but got `%s`", owner, nodes[0])
+	}
+
+	nodes, _ = h.GetTopNodes("hello", 10)
+	if len(nodes) != 3 {
+		t.Errorf("Expected all the 3 nodes to be returned, but got %v", nodes)
+	}
+
+	_, err = h.GetTopNodes("hello", 0)
+	if err == nil {
+		t.Error("Expected non-nil error as n is 0 but got nil")
+	}
+}
+
+func TestWHEN_Hash_THEN_SameOwnerAsGetTopNodesWithoutAllocation(t *testing.T) {
+	h := &RendezvousHashing{}
+
+	for i := 0; i < 100; i++ {
+		h.AddNode(fmt.Sprintf("node%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		node, _ := h.Hash(key, 0)
+		top, _ := h.GetTopNodes(key, 3)
+
+		if node != top[0] {
+			t.Errorf("Expected key `%s` to be owned by `%s`, but got `%s`", key, top[0], node)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Hash("key-1", 0)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected Hash to not allocate, but got %f allocations", allocs)
+	}
+}
@@ -24,52 +24,188 @@ package uniform
 
 import (
 	"errors"
-	"log"
+	"log/slog"
+	"math/bits"
+	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
+	"github.com/kounkou/hasherprovider/internal/logging"
 )
 
+// UniformHashing is safe for concurrent use. The named nodes are copied on change
+// and published atomically, so that lookups never take a lock.
+
 type UniformHashing struct {
-	values []int
-	Logger *log.Logger
+	Logger   *slog.Logger
+	HashFunc hashfunc.HashFunc
+	mu       sync.Mutex
+	nodes    atomic.Pointer[nodes]
+}
+
+// The named nodes, which must NOT be changed once published, and the fraction of
+// the keys which changed owner when they were published
+type nodes struct {
+	names []string
+	moved float64
 }
 
 // Uniform hashing is used to distribute the uuid's associated (example events...)
 // across the a set of shards indexed.
 // Uniform hashing makes sense when the number of shards is fixed. For dynamic shards
-// please consider using `consistent hashing`
-func (h UniformHashing) Hash(uuid string, shards int) (string, error) {
-	if shards == 0 || len(uuid) == 0 {
-		h.Logger.Println("[ERROR] Uniform Hashing ", uuid, " failed with ", shards, " shards")
+// please consider using `consistent hashing`.
+// The unsigned 64 bits hash of the uuid is reduced to the range of the shards with
+// Lemire's multiply-shift, (hash * shards) >> 64, which unlike the modulo does not
+// favour the lowest shards.
+// When named nodes were added with AddNode, the name of the node at the reduced
+// index is returned instead of the index, and shards is ignored
+func (h *UniformHashing) Hash(uuid string, shards int) (string, error) {
+	if names := h.snapshot().names; len(names) > 0 && len(uuid) > 0 {
+		return names[h.computeIndex(uuid, len(names))], nil
+	}
+
+	if shards <= 0 || len(uuid) == 0 {
+		h.logger().Error("Hash failed", logging.KeyHash(uuid), "shards", shards)
 		return "", errors.New("Expected shards to be positive non 0")
 	}
 
-	hash := 0
-	for i := 0; i < len(uuid); i++ {
-		hash = (hash << 5) + hash + int(uuid[i])
+	return strconv.Itoa(h.computeIndex(uuid, shards)), nil
+}
+
+// Nodes returns the named nodes, in the order of their indexes
+func (h *UniformHashing) Nodes() []string {
+	return append([]string(nil), h.snapshot().names...)
+}
+
+// MovedFraction returns the fraction of the keys which changed owner during the
+// last membership change, triggered by AddNode or RemoveNode
+func (h *UniformHashing) MovedFraction() float64 {
+	return h.snapshot().moved
+}
+
+// AddNode will add a named node at the end of the nodes. As the index of a key is
+// reduced from its hash and len(nodes), most of the keys change owner : the fraction
+// of the keys which moved is reported by MovedFraction. Adding a node which is
+// already present has no effect
+func (h *UniformHashing) AddNode(node string) error {
+	h.logger().Info("AddNode", "node", node)
+
+	if len(node) == 0 {
+		h.logger().Error("AddNode failed", "node", node)
+		return errors.New("Expected node to be non-empty")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot().names
+	for _, n := range current {
+		if n == node {
+			h.logger().Warn("AddNode node already present", "node", node)
+			return nil
+		}
 	}
-	return strconv.Itoa(hash % shards), nil
+
+	names := make([]string, 0, len(current)+1)
+	names = append(names, current...)
+	names = append(names, node)
+
+	h.publish(current, names)
+
+	return nil
 }
 
-// Implemented for convenience, Uniformhashing does NOT support AddNode as the Uniformhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the Uniform Hashing algorithm
-func (h UniformHashing) AddNode(_ string) {
-	panic("AddNode method is not implemented for UniformHashing")
+// RemoveNode will remove a named node, shifting the index of the following nodes.
+// The fraction of the keys which moved is reported by MovedFraction. Removing a
+// node which is not present has no effect
+func (h *UniformHashing) RemoveNode(node string) error {
+	h.logger().Info("RemoveNode", "node", node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.snapshot().names
+	for i, n := range current {
+		if n == node {
+			names := make([]string, 0, len(current)-1)
+			names = append(names, current[:i]...)
+			names = append(names, current[i+1:]...)
+
+			h.publish(current, names)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Private function not exported returning the current named nodes
+func (h *UniformHashing) snapshot() *nodes {
+	if n := h.nodes.Load(); n != nil {
+		return n
+	}
+	return &nodes{}
 }
 
-// Implemented for convenience, Uniformhashing does NOT support RemoveNode as the Uniformhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the Uniform Hashing algorithm
-func (h UniformHashing) RemoveNode(_ string) {
-	panic("RemoveNode method is not implemented for UniformHashing")
+// Private function not exported publishing the new named nodes along with the
+// fraction of the keys which moved. The reduction splits the hashes into len(nodes)
+// equal ranges, so a key whose hash is at the fraction x of the hashes moves when
+// before[x * len(before)] differs from after[x * len(after)]. The fraction of the keys
+// which moved is the total length of the ranges where the owners differ.
+// It must be called with the lock held
+func (h *UniformHashing) publish(before []string, after []string) {
+	moved := 1.0
+
+	if len(before) > 0 && len(after) > 0 {
+		n, m := len(before), len(after)
+
+		// the boundaries of the ranges, i/n and j/m, compared as i*m and j*n over n*m
+		boundaries := make([]int, 0, n+m+1)
+		for i := 0; i < n; i++ {
+			boundaries = append(boundaries, i*m)
+		}
+		for j := 0; j < m; j++ {
+			boundaries = append(boundaries, j*n)
+		}
+		boundaries = append(boundaries, n*m)
+		sort.Ints(boundaries)
+
+		changed := 0
+		for k := 0; k < len(boundaries)-1; k++ {
+			start, end := boundaries[k], boundaries[k+1]
+			if start != end && before[start/m] != after[start/n] {
+				changed += end - start
+			}
+		}
+
+		moved = float64(changed) / float64(n*m)
+	}
+
+	h.nodes.Store(&nodes{names: after, moved: moved})
+
+	h.logger().Info("Nodes changed", "nodes", len(after), "moved", moved)
+}
+
+// Private function not exported to be able to compute the index of the provided
+// key among n indexes. The hash, FNV-1a 64 bits by default, is mixed first so that
+// its high bits, which the reduction relies on, are uniform even for the 32 bits
+// hash functions
+func (h *UniformHashing) computeIndex(uuid string, n int) int {
+	hashFunc := h.HashFunc
+	if hashFunc == nil {
+		hashFunc = hashfunc.FNV1a64
+	}
+
+	hash := hashfunc.Mix64(hashFunc.Sum64([]byte(uuid)))
+
+	index, _ := bits.Mul64(hash, uint64(n))
+	return int(index)
 }
 
-// Implemented for convenience, Uniformhashing does NOT support SetReplicas as the Uniformhashing
-// does NOT need to be ring like for Consistent Hashing.
-// This function will `panic`, as using this function in the client application is not an intended use of
-// the Uniform Hashing algorithm
-func (h *UniformHashing) SetReplicas(_ int) {
-	panic("SetReplicas method is not implemented for UniformHashing")
+// Private function not exported returning the logger of the hasher, which drops
+// every record when no logger was set
+func (h *UniformHashing) logger() *slog.Logger {
+	return logging.OrDiscard(h.Logger)
 }
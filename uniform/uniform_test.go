@@ -1,9 +1,15 @@
 package uniform
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/kounkou/hasherprovider/hashfunc"
 )
 
 type Tuple struct {
@@ -14,7 +20,7 @@ type Tuple struct {
 
 func TestWHEN_HashFunctionCalledWithNullEvent_THEN_NullPointerExceptionThrown(t *testing.T) {
 	hasher := &UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	event := ""
@@ -28,7 +34,7 @@ func TestWHEN_HashFunctionCalledWithNullEvent_THEN_NullPointerExceptionThrown(t
 
 func TestWHEN_HashFunctionCalledWithNullShards_THEN_NullPointerExceptionThrown(t *testing.T) {
 	hasher := &UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	event := "1"
@@ -42,14 +48,14 @@ func TestWHEN_HashFunctionCalledWithNullShards_THEN_NullPointerExceptionThrown(t
 
 func TestWHEN_HashFunctionCalledWithKeyAndShardNumbers_THEN_ResultMatchesExpected(t *testing.T) {
 	hasher := &UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
 	eventList := []Tuple{
 		{"1Test", 1, "0"},
-		{"2Hello", 4, "2"},
+		{"2Hello", 4, "0"},
 		{"Test 3", 5, "4"},
-		{"hello", 2, "0"},
+		{"hello", 2, "1"},
 	}
 
 	for _, v := range eventList {
@@ -63,56 +69,129 @@ func TestWHEN_HashFunctionCalledWithKeyAndShardNumbers_THEN_ResultMatchesExpecte
 	}
 }
 
-func TestUniformHashing_AddNode(t *testing.T) {
-	h := UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+func TestWHEN_NamedNodesAdded_THEN_HashReturnsNodeName(t *testing.T) {
+	hasher := &UniformHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	expectedError := "AddNode method is not implemented for UniformHashing"
+	hasher.AddNode("server1")
+	hasher.AddNode("server2")
+	hasher.AddNode("server3")
+	hasher.AddNode("server3")
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("AddNode did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("AddNode panicked with error message '%s', but expected '%s'", r, expectedError)
+	if len(hasher.Nodes()) != 3 {
+		t.Errorf("Expected 3 nodes, but got %v", hasher.Nodes())
+	}
+
+	assigned := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		result, err := hasher.Hash(fmt.Sprintf("key-%d", i), 0)
+		if err != nil {
+			t.Errorf("Expected no errors to occur but got %s", err)
 		}
-	}()
+		assigned[result]++
+	}
 
-	h.AddNode("node")
+	if len(assigned) != 3 || assigned["server1"] == 0 || assigned["server2"] == 0 || assigned["server3"] == 0 {
+		t.Errorf("Expected keys to be assigned to the 3 nodes, but got %v", assigned)
+	}
+
+	if err := hasher.AddNode(""); err == nil {
+		t.Error("Expected non-nil error as node is empty but got nil")
+	}
 }
 
-func TestUniformHashing_RemoveNode(t *testing.T) {
-	h := UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+func TestWHEN_MembershipChanges_THEN_MovedFractionReported(t *testing.T) {
+	hasher := &UniformHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	expectedError := "RemoveNode method is not implemented for UniformHashing"
+	hasher.AddNode("a")
+	hasher.AddNode("b")
+	hasher.AddNode("c")
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("RemoveNode did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("RemoveNode panicked with error message '%s', but expected '%s'", r, expectedError)
+	keys := 10000
+	before := make([]string, keys)
+	for i := range before {
+		before[i], _ = hasher.Hash(fmt.Sprintf("key-%d", i), 0)
+	}
+
+	// from 3 to 4 nodes, the keys in [1/4, 1/3), [1/2, 2/3) and [3/4, 1) of the hashes move
+	hasher.AddNode("d")
+	if hasher.MovedFraction() != 0.5 {
+		t.Errorf("Expected 0.5 of the keys to move, but got %f", hasher.MovedFraction())
+	}
+
+	moved := 0
+	for i := range before {
+		if after, _ := hasher.Hash(fmt.Sprintf("key-%d", i), 0); after != before[i] {
+			moved++
 		}
-	}()
+	}
+
+	if fraction := float64(moved) / float64(keys); fraction < 0.45 || fraction > 0.55 {
+		t.Errorf("Expected about 0.5 of the keys to move, but %f moved", fraction)
+	}
+
+	hasher.RemoveNode("d")
+	hasher.RemoveNode("b")
 
-	h.RemoveNode("node")
+	// from [a b c] to [a c], the keys in [1/3, 2/3) of the hashes move from b
+	if hasher.MovedFraction() != 1.0/3.0 {
+		t.Errorf("Expected 1/3 of the keys to move, but got %f", hasher.MovedFraction())
+	}
 }
 
-func TestUniformHashing_SetReplicas(t *testing.T) {
-	h := UniformHashing{
-		Logger: log.New(os.Stdout, "hashProfiler: ", log.LstdFlags),
+func TestWHEN_LongUUID_THEN_ShardIsNeverNegative(t *testing.T) {
+	hasher := &UniformHashing{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
 	}
 
-	expectedError := "SetReplicas method is not implemented for UniformHashing"
+	for i := 0; i < 1000; i++ {
+		uuid := strings.Repeat(fmt.Sprintf("f47ac10b-58cc-4372-a567-0e02b2c3d479-%d", i), 10)
+		result, _ := hasher.Hash(uuid, 7)
+
+		if shard, err := strconv.Atoi(result); err != nil || shard < 0 || shard >= 7 {
+			t.Errorf("Hash(%s, 7) = %s; expected a shard in [0, 7)", uuid, result)
+		}
+	}
+
+	if _, err := hasher.Hash("1", -3); err == nil {
+		t.Error("Expected non-nil error as shards number is negative but got nil")
+	}
+}
+
+// The chi-squared statistic of the number of keys per shard must stay below the
+// critical value for a significance level of 0.001, approximated with Wilson-Hilferty
+func TestWHEN_ManyKeys_THEN_ShardsEvenlyDistributed(t *testing.T) {
+	keys := 100000
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("SetReplicas did not panic with error message '%s'", expectedError)
-		} else if r != expectedError {
-			t.Errorf("SetReplicas panicked with error message '%s', but expected '%s'", r, expectedError)
+	for _, hashFunc := range []hashfunc.HashFunc{nil, hashfunc.FNV1a32, hashfunc.CRC32, hashfunc.XXHash64} {
+		hasher := &UniformHashing{
+			Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			HashFunc: hashFunc,
 		}
-	}()
 
-	h.SetReplicas(4)
+		for _, shards := range []int{2, 3, 7, 10, 16, 100, 1000} {
+			counts := make([]int, shards)
+			for i := 0; i < keys; i++ {
+				result, _ := hasher.Hash(fmt.Sprintf("user-%d", i), shards)
+				shard, _ := strconv.Atoi(result)
+				counts[shard]++
+			}
+
+			expected := float64(keys) / float64(shards)
+			chiSquared := 0.0
+			for _, count := range counts {
+				chiSquared += (float64(count) - expected) * (float64(count) - expected) / expected
+			}
+
+			df := float64(shards - 1)
+			critical := df * math.Pow(1-2/(9*df)+3.09*math.Sqrt(2/(9*df)), 3)
+
+			if chiSquared > critical {
+				t.Errorf("Expected keys to be evenly distributed over %d shards, but chi-squared is %f > %f", shards, chiSquared, critical)
+			}
+		}
+	}
 }